@@ -0,0 +1,45 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/spf13/cobra"
+)
+
+var (
+	clearAllCacheFlag bool
+)
+
+var clearCacheCmd = &cobra.Command{
+	Use:   "clear-cache",
+	Short: "Clear the cache of IR compiled from YAML",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cacheDir, err := conjureplugin.DefaultIRCacheDir()
+		if err != nil {
+			return err
+		}
+		cache := conjureplugin.NewIRCache(cacheDir)
+		if clearAllCacheFlag {
+			return cache.Clear()
+		}
+		return cache.ClearCompiled()
+	},
+}
+
+func init() {
+	clearCacheCmd.Flags().BoolVar(&clearAllCacheFlag, "all", false, "also clear the IR fetched from remote sources")
+	rootCmd.AddCommand(clearCacheCmd)
+}
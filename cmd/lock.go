@@ -0,0 +1,62 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	updateLockFlag bool
+)
+
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Verify or update the Conjure lockfile",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		remoteParams, err := defaultRemoteParams(conjureplugin.RemoteOfflineParam(offlineFlag))
+		if err != nil {
+			return err
+		}
+		cfgFile, err := filepath.Abs(configFileFlag)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err := os.Chdir(projectDirFlag); err != nil {
+			return errors.Wrapf(err, "failed to set working directory")
+		}
+		projectParams, err := toProjectParams(cfgFile, remoteParams...)
+		if err != nil {
+			return err
+		}
+		return conjureplugin.Lock(projectParams, lockfilePath(cfgFile), updateLockFlag, cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	lockCmd.Flags().BoolVar(&updateLockFlag, "update", false, "update the lockfile to match the IR that is currently resolved")
+	lockCmd.Flags().BoolVar(&offlineFlag, "offline", false, "only use cached IR for remote sources and fail if it is not in the cache")
+	rootCmd.AddCommand(lockCmd)
+}
+
+// lockfilePath returns the path of the lockfile for the provided configuration file.
+func lockfilePath(cfgFile string) string {
+	return filepath.Join(filepath.Dir(cfgFile), conjureplugin.LockfileName)
+}
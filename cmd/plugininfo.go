@@ -41,6 +41,16 @@ var (
 				pluginapi.VerifyOptionsApplyFalseArgs("--"+VerifyFlagName),
 			),
 		),
+		pluginapi.PluginInfoTaskInfo(
+			"conjure-lock",
+			"Verify or update the Conjure lockfile",
+			pluginapi.TaskInfoCommand("lock"),
+		),
+		pluginapi.PluginInfoTaskInfo(
+			"conjure-clear-cache",
+			"Clear the cache of IR compiled from YAML",
+			pluginapi.TaskInfoCommand("clear-cache"),
+		),
 		pluginapi.PluginInfoTaskInfo(
 			"conjure-publish",
 			"Publish Conjure IR",
@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"os"
+	"path/filepath"
 
 	"github.com/palantir/distgo/distgo"
 	"github.com/palantir/distgo/publisher"
@@ -40,13 +41,25 @@ var publishCmd = &cobra.Command{
 	Use:   "publish",
 	Short: "Publish Conjure IR",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		projectParams, err := toProjectParams(configFileFlag)
+		remoteParams, err := defaultRemoteParams()
 		if err != nil {
 			return err
 		}
+		// the configuration file is read after the working directory is changed, so resolve it first
+		cfgFile, err := filepath.Abs(configFileFlag)
+		if err != nil {
+			return errors.WithStack(err)
+		}
 		if err := os.Chdir(projectDirFlag); err != nil {
 			return errors.Wrapf(err, "failed to set working directory")
 		}
+		projectParams, err := toProjectParams(cfgFile, remoteParams...)
+		if err != nil {
+			return err
+		}
+		if projectParams, err = projectParams.FilterProjects(projectFlag, excludeProjectFlag); err != nil {
+			return err
+		}
 
 		publisherFlags, err := conjureplugin.PublisherFlags()
 		if err != nil {
@@ -78,5 +91,6 @@ func init() {
 	publishCmd.Flags().StringVar(&usernameFlagVal, string(publisher.ConnectionInfoUsernameFlag.Name), "", publisher.ConnectionInfoUsernameFlag.Description)
 	publishCmd.Flags().StringVar(&passwordFlagVal, string(publisher.ConnectionInfoPasswordFlag.Name), "", publisher.ConnectionInfoPasswordFlag.Description)
 	publishCmd.Flags().BoolVar(&mavenNoPOMFlagVal, string(maven.NoPOMFlag.Name), false, maven.NoPOMFlag.Description)
+	addProjectFlags(publishCmd)
 	rootCmd.AddCommand(publishCmd)
 }
@@ -15,7 +15,11 @@
 package cmd
 
 import (
+	"context"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
 
 	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
 	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin/config"
@@ -24,33 +28,146 @@ import (
 )
 
 var (
-	verifyFlag bool
+	verifyFlag       bool
+	offlineFlag      bool
+	updatePinsFlag   bool
+	parallelismFlag  int
+	diffContextFlag  int
+	diffMaxLinesFlag int
+	formatFlag       string
+
+	projectFlag        []string
+	excludeProjectFlag []string
+	forceFlag          bool
+	runDryRunFlag      bool
+
+	watchFlag             bool
+	watchPollIntervalFlag time.Duration
+	watchDebounceFlag     time.Duration
 )
 
+// VerifyFormatEnvVar is the environment variable that sets the format of the verify report if the format flag is not
+// specified. It allows the format to be configured when the task is run by the godel verify task, which does not
+// accept task-specific flags.
+const VerifyFormatEnvVar = "GODEL_CONJURE_VERIFY_FORMAT"
+
+const formatFlagName = "format"
+
 var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Run conjure-go based on project configuration",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		parsedConfigSet, err := toProjectParams(configFileFlag)
+		if !cmd.Flags().Changed(formatFlagName) {
+			formatFlag = os.Getenv(VerifyFormatEnvVar)
+		}
+		verifyFormat, err := conjureplugin.ParseVerifyFormat(formatFlag)
+		if err != nil {
+			return err
+		}
+		remoteParams, err := defaultRemoteParams(conjureplugin.RemoteOfflineParam(offlineFlag))
 		if err != nil {
 			return err
 		}
+		// the configuration file is read after the working directory is changed, so resolve it first
+		cfgFile, err := filepath.Abs(configFileFlag)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		absProjectDir, err := filepath.Abs(projectDirFlag)
+		if err != nil {
+			return errors.WithStack(err)
+		}
 		if err := os.Chdir(projectDirFlag); err != nil {
 			return errors.Wrapf(err, "failed to set working directory")
 		}
-		return conjureplugin.Run(parsedConfigSet, verifyFlag, projectDirFlag, cmd.OutOrStdout())
+		if watchFlag && verifyFlag {
+			return errors.Errorf("--watch cannot be used with --%s", VerifyFlagName)
+		}
+		if runDryRunFlag && (verifyFlag || watchFlag || updatePinsFlag) {
+			return errors.Errorf("--dry-run cannot be used with --%s, --watch or --update-pins", VerifyFlagName)
+		}
+		if updatePinsFlag {
+			if verifyFlag {
+				return errors.Errorf("--update-pins cannot be used with --%s", VerifyFlagName)
+			}
+			if err := config.UpdatePinsInFile(cfgFile, remoteParams...); err != nil {
+				return errors.Wrapf(err, "failed to update pins")
+			}
+		}
+		parsedConfigSet, err := toProjectParams(cfgFile, remoteParams...)
+		if err != nil {
+			return err
+		}
+		runParams := []conjureplugin.RunParam{
+			conjureplugin.LockfileParam(lockfilePath(cfgFile)),
+			conjureplugin.ParallelismParam(parallelismFlag),
+			conjureplugin.DiffContextParam(diffContextFlag),
+			conjureplugin.DiffMaxLinesParam(diffMaxLinesFlag),
+			conjureplugin.VerifyFormatParam(verifyFormat),
+			conjureplugin.ProjectsParam(projectFlag, excludeProjectFlag),
+			conjureplugin.StateFileParam(stateFilePath(absProjectDir)),
+			conjureplugin.ForceParam(forceFlag),
+			conjureplugin.DryRunParam(runDryRunFlag),
+		}
+		if watchFlag {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+			return conjureplugin.Watch(ctx, parsedConfigSet, projectDirFlag, cmd.OutOrStdout(), watchPollIntervalFlag, watchDebounceFlag, runParams...)
+		}
+		return conjureplugin.Run(parsedConfigSet, verifyFlag, projectDirFlag, cmd.OutOrStdout(), runParams...)
 	},
 }
 
 func init() {
 	runCmd.Flags().BoolVar(&verifyFlag, VerifyFlagName, false, "verify that current project matches output of conjure")
+	runCmd.Flags().BoolVar(&offlineFlag, "offline", false, "only use cached IR for remote sources and fail if it is not in the cache")
+	runCmd.Flags().BoolVar(&updatePinsFlag, "update-pins", false, "update the sha256 values in the configuration to match the IR currently provided by the locators")
+	runCmd.Flags().IntVar(&parallelismFlag, "parallelism", 1, "maximum number of projects that are generated concurrently (0 uses the number of CPUs)")
+	runCmd.Flags().IntVar(&diffContextFlag, "diff-context", 3, "number of lines of context in the diffs printed by verify")
+	runCmd.Flags().IntVar(&diffMaxLinesFlag, "diff-max-lines", 200, "maximum number of lines of the diff of a single file printed by verify (0 for no limit)")
+	runCmd.Flags().StringVar(&formatFlag, formatFlagName, string(conjureplugin.VerifyFormatText), "format of the report printed by verify: text, json or sarif (defaults to the value of "+VerifyFormatEnvVar+" if set)")
+	runCmd.Flags().BoolVar(&forceFlag, "force", false, "generate every project even if its output is up to date")
+	runCmd.Flags().BoolVar(&runDryRunFlag, "dry-run", false, "print the files that would be created, modified, left unchanged or deleted without modifying any files")
+	runCmd.Flags().BoolVar(&watchFlag, "watch", false, "watch the local YAML and IR files of the projects and generate the projects again when they change")
+	runCmd.Flags().DurationVar(&watchPollIntervalFlag, "watch-poll-interval", 500*time.Millisecond, "interval at which the files watched by --watch are polled for changes")
+	runCmd.Flags().DurationVar(&watchDebounceFlag, "watch-debounce", time.Second, "duration without further changes that --watch waits for before generating changed projects")
+	addProjectFlags(runCmd)
 	rootCmd.AddCommand(runCmd)
 }
 
-func toProjectParams(cfgFile string) (conjureplugin.ConjureProjectParams, error) {
+// stateFilePath returns the path of the file that records the state of the generated output of the provided project
+// directory.
+func stateFilePath(projectDir string) string {
+	return filepath.Join(projectDir, "build", conjureplugin.StateFileName)
+}
+
+// addProjectFlags adds the flags that select the projects that are processed by the provided command.
+func addProjectFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&projectFlag, "project", nil, "key of a project to process (can be a glob and can be specified multiple times; all projects are processed if not specified)")
+	cmd.Flags().StringArrayVar(&excludeProjectFlag, "exclude-project", nil, "key of a project not to process (can be a glob and can be specified multiple times)")
+}
+
+// toProjectParams returns the parameters specified by the provided configuration file. The provided remote parameters
+// are applied to all remote providers. IR compiled from YAML is cached in the default cache directory.
+func toProjectParams(cfgFile string, remoteParams ...conjureplugin.RemoteParam) (conjureplugin.ConjureProjectParams, error) {
 	config, err := config.ReadConfigFromFile(cfgFile)
 	if err != nil {
 		return conjureplugin.ConjureProjectParams{}, err
 	}
-	return config.ToParams()
+	cacheDir, err := conjureplugin.DefaultIRCacheDir()
+	if err != nil {
+		return conjureplugin.ConjureProjectParams{}, err
+	}
+	yamlParams := []conjureplugin.YAMLParam{conjureplugin.YAMLCacheParam(conjureplugin.NewIRCache(cacheDir))}
+	return config.ToParamsWithYAMLParams(yamlParams, remoteParams...)
+}
+
+// defaultRemoteParams returns the remote parameters that configure IR fetched from remote sources to be cached in the
+// default cache directory along with the provided parameters.
+func defaultRemoteParams(params ...conjureplugin.RemoteParam) ([]conjureplugin.RemoteParam, error) {
+	cacheDir, err := conjureplugin.DefaultIRCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return append([]conjureplugin.RemoteParam{conjureplugin.RemoteCacheParam(conjureplugin.NewIRCache(cacheDir))}, params...), nil
 }
@@ -0,0 +1,222 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mholt/archiver"
+	"github.com/pkg/errors"
+)
+
+var _ IRProvider = &archiveIRProvider{}
+var _ LocatorResolver = &archiveIRProvider{}
+
+type archiveIRProvider struct {
+	archive string
+	entry   string
+	remoteConfig
+
+	// mu guards the path and content of the entry, which are read once so that the IR and the resolved locator are
+	// always from the same read of the archive.
+	mu           sync.Mutex
+	entryPath    string
+	entryContent []byte
+}
+
+// NewArchiveIRProvider returns an IRProvider that provides IR read from an entry of an archive. The archive can be a
+// local path, a "file://" URL or a remote URL, and its format is determined by its extension: ".zip" and ".jar"
+// archives are read as zip files, ".tgz" and ".tar.gz" archives as gzipped tarballs and ".tar" archives as tarballs.
+// The entry is the slash-separated path of the IR file within the archive and may be a glob pattern as supported by
+// path.Match. If the entry is empty, the archive entries whose names end in ".conjure.json" are considered. It is an
+// error for the entry to match anything other than exactly one file.
+func NewArchiveIRProvider(archive, entry string, params ...RemoteParam) (IRProvider, error) {
+	if _, err := archiveFormat(archive); err != nil {
+		return nil, err
+	}
+	if _, err := path.Match(entry, ""); err != nil {
+		return nil, errors.Wrapf(err, "invalid archive entry pattern %q", entry)
+	}
+	return &archiveIRProvider{
+		archive:      archive,
+		entry:        entry,
+		remoteConfig: newRemoteConfig(params...),
+	}, nil
+}
+
+func (p *archiveIRProvider) IRBytes() ([]byte, error) {
+	_, irBytes, err := p.readEntry()
+	return irBytes, err
+}
+
+func (p *archiveIRProvider) GeneratedFromYAML() bool {
+	return false
+}
+
+// ResolvedLocator returns the location of the archive and the path of the entry separated by "!".
+func (p *archiveIRProvider) ResolvedLocator() (string, error) {
+	entryPath, _, err := p.readEntry()
+	if err != nil {
+		return "", err
+	}
+	archiveLocator := p.archive
+	if _, ok := p.localArchivePath(); !ok {
+		archiveLocator = redactURL(p.archive)
+	}
+	return archiveLocator + "!" + entryPath, nil
+}
+
+// readEntry returns the path and content of the entry of the archive that matches the entry of the provider. The
+// archive is only read the first time that the entry is read successfully.
+func (p *archiveIRProvider) readEntry() (string, []byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.entryContent == nil {
+		entryPath, entryContent, err := p.readArchiveEntry()
+		if err != nil {
+			return "", nil, err
+		}
+		p.entryPath, p.entryContent = entryPath, entryContent
+	}
+	return p.entryPath, p.entryContent, nil
+}
+
+// readArchiveEntry reads the archive and returns the path and content of the entry that matches the entry of the
+// provider.
+func (p *archiveIRProvider) readArchiveEntry() (rEntryPath string, rContent []byte, rErr error) {
+	archiveBytes, err := p.readArchive()
+	if err != nil {
+		return "", nil, err
+	}
+	format, err := archiveFormat(p.archive)
+	if err != nil {
+		return "", nil, err
+	}
+
+	tmpDir, err := ioutil.TempDir("", "conjure-archive-")
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to create temporary directory")
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); rErr == nil && err != nil {
+			rErr = errors.Wrapf(err, "failed to remove temporary directory")
+		}
+	}()
+	if err := format.Read(bytes.NewReader(archiveBytes), tmpDir); err != nil {
+		return "", nil, errors.Wrapf(err, "failed to extract archive %s", p.displayName())
+	}
+
+	var matches []string
+	if err := filepath.Walk(tmpDir, func(currPath string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		relPath, err := filepath.Rel(tmpDir, currPath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		if p.entry == "" {
+			if strings.HasSuffix(relPath, ".conjure.json") {
+				matches = append(matches, relPath)
+			}
+			return nil
+		}
+		if ok, _ := path.Match(p.entry, relPath); ok {
+			matches = append(matches, relPath)
+		}
+		return nil
+	}); err != nil {
+		return "", nil, errors.Wrapf(err, "failed to read entries of archive %s", p.displayName())
+	}
+	sort.Strings(matches)
+
+	entryDesc := p.entry
+	if entryDesc == "" {
+		entryDesc = "*.conjure.json"
+	}
+	switch len(matches) {
+	case 0:
+		return "", nil, errors.Errorf("archive %s does not contain an entry that matches %s", p.displayName(), entryDesc)
+	case 1:
+	default:
+		return "", nil, errors.Errorf("archive %s contains multiple entries that match %s: %s", p.displayName(), entryDesc, strings.Join(matches, ", "))
+	}
+	content, err := ioutil.ReadFile(filepath.Join(tmpDir, filepath.FromSlash(matches[0])))
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	return matches[0], content, nil
+}
+
+// readArchive returns the content of the archive, which is fetched if it is remote.
+func (p *archiveIRProvider) readArchive() ([]byte, error) {
+	localPath, ok := p.localArchivePath()
+	if !ok {
+		return p.fetch(p.archive)
+	}
+	archiveBytes, err := ioutil.ReadFile(localPath)
+	if os.IsNotExist(err) {
+		return nil, errors.Errorf("archive %s does not exist", localPath)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return archiveBytes, nil
+}
+
+// localArchivePath returns the local path of the archive if the archive is a "file://" URL or a path.
+func (p *archiveIRProvider) localArchivePath() (string, bool) {
+	parsedURL, err := url.Parse(p.archive)
+	if err != nil || parsedURL.Scheme == "" {
+		return p.archive, true
+	}
+	if parsedURL.Scheme == "file" {
+		return parsedURL.Path, true
+	}
+	return "", false
+}
+
+func (p *archiveIRProvider) displayName() string {
+	if localPath, ok := p.localArchivePath(); ok {
+		return localPath
+	}
+	return redactURL(p.archive)
+}
+
+// archiveFormat returns the format of the provided archive based on its extension.
+func archiveFormat(archive string) (archiver.Archiver, error) {
+	name := strings.ToLower(archive)
+	if parsedURL, err := url.Parse(archive); err == nil && parsedURL.Scheme != "" {
+		name = strings.ToLower(parsedURL.Path)
+	}
+	switch {
+	case strings.HasSuffix(name, ".zip"), strings.HasSuffix(name, ".jar"):
+		return archiver.Zip, nil
+	case strings.HasSuffix(name, ".tgz"), strings.HasSuffix(name, ".tar.gz"):
+		return archiver.TarGz, nil
+	case strings.HasSuffix(name, ".tar"):
+		return archiver.Tar, nil
+	default:
+		return nil, errors.Errorf("archive %s must have one of the extensions .zip, .jar, .tgz, .tar.gz or .tar", redactURL(archive))
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultIRCommandTimeout is the timeout used for an IRCommand that does not specify one.
+const DefaultIRCommandTimeout = 5 * time.Minute
+
+// maxCommandStderrLen is the maximum number of bytes of the standard error output of a command that are included in
+// errors.
+const maxCommandStderrLen = 4096
+
+// IRCommand specifies a command that produces IR.
+type IRCommand struct {
+	// Executable is the name or path of the executable that is run.
+	Executable string
+	// Args are the arguments provided to the executable.
+	Args []string
+	// Dir is the working directory of the command. If empty, the current working directory is used.
+	Dir string
+	// OutputFile is the path of the file to which the command writes the IR. A relative path is resolved against the
+	// working directory of the command. The file is removed before the command runs. If empty, the IR is read from the
+	// standard output of the command.
+	OutputFile string
+	// Timeout is the maximum duration of the command. If zero, DefaultIRCommandTimeout is used.
+	Timeout time.Duration
+}
+
+var _ IRProvider = &commandIRProvider{}
+var _ LocatorResolver = &commandIRProvider{}
+
+type commandIRProvider struct {
+	command IRCommand
+}
+
+// NewCommandIRProvider returns an IRProvider that provides IR produced by running the provided command. The command is
+// run every time the IR is requested. If the command fails, the returned error includes its standard error output.
+func NewCommandIRProvider(command IRCommand) (IRProvider, error) {
+	if command.Executable == "" {
+		return nil, errors.Errorf("executable must be specified for command")
+	}
+	if command.Timeout < 0 {
+		return nil, errors.Errorf("timeout for command %s cannot be negative", command.Executable)
+	}
+	return &commandIRProvider{
+		command: command,
+	}, nil
+}
+
+func (p *commandIRProvider) IRBytes() (rIRBytes []byte, rErr error) {
+	timeout := p.command.Timeout
+	if timeout == 0 {
+		timeout = DefaultIRCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// the output of the command is written to files rather than pipes so that the command does not block on processes
+	// that it started that outlive it after it is killed
+	tmpDir, err := ioutil.TempDir("", "conjure-command-")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create temporary directory")
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); rErr == nil && err != nil {
+			rErr = errors.Wrapf(err, "failed to remove temporary directory")
+		}
+	}()
+	stdout, err := os.Create(filepath.Join(tmpDir, "stdout"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() {
+		_ = stdout.Close()
+	}()
+	stderr, err := os.Create(filepath.Join(tmpDir, "stderr"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() {
+		_ = stderr.Close()
+	}()
+
+	outputFile := stdout.Name()
+	if p.command.OutputFile != "" {
+		outputFile = p.command.OutputFile
+		if !filepath.IsAbs(outputFile) {
+			outputFile = filepath.Join(p.command.Dir, outputFile)
+		}
+		// an output file left by a previous run must never be read as the output of this run
+		if err := os.Remove(outputFile); err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrapf(err, "failed to remove previous output of command %s", p.command.Executable)
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, p.command.Executable, p.command.Args...)
+	cmd.Dir = p.command.Dir
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		stderrBytes, _ := ioutil.ReadFile(stderr.Name())
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, errors.Errorf("command %s timed out after %v%s", p.command.Executable, timeout, stderrSuffix(string(stderrBytes)))
+		}
+		return nil, errors.Wrapf(err, "command %s failed%s", p.command.Executable, stderrSuffix(string(stderrBytes)))
+	}
+
+	irBytes, err := ioutil.ReadFile(outputFile)
+	if os.IsNotExist(err) {
+		return nil, errors.Errorf("command %s did not write its output file %s", p.command.Executable, outputFile)
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "failed to read output of command %s", p.command.Executable)
+	}
+	return irBytes, nil
+}
+
+func (p *commandIRProvider) GeneratedFromYAML() bool {
+	return false
+}
+
+// ResolvedLocator returns the executable and arguments of the command separated by spaces.
+func (p *commandIRProvider) ResolvedLocator() (string, error) {
+	return strings.Join(append([]string{p.command.Executable}, p.command.Args...), " "), nil
+}
+
+// stderrSuffix returns a suffix for an error message that contains the provided standard error output. Only the end of
+// the output is included if it is long. Returns an empty string if the output is empty.
+func stderrSuffix(stderr string) string {
+	stderr = strings.TrimSpace(stderr)
+	if stderr == "" {
+		return ""
+	}
+	if len(stderr) > maxCommandStderrLen {
+		stderr = "..." + stderr[len(stderr)-maxCommandStderrLen:]
+	}
+	return " with stderr:\n" + stderr
+}
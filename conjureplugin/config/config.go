@@ -16,13 +16,18 @@ package config
 
 import (
 	"io/ioutil"
+	"net/http"
 	"net/url"
 	"os"
+	"reflect"
+	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
 	v1 "github.com/palantir/godel-conjure-plugin/v6/conjureplugin/config/internal/v1"
+	"github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
 )
@@ -33,16 +38,35 @@ func ToConjurePluginConfig(in *ConjurePluginConfig) *v1.ConjurePluginConfig {
 	return (*v1.ConjurePluginConfig)(in)
 }
 
-func (c *ConjurePluginConfig) ToParams() (conjureplugin.ConjureProjectParams, error) {
+// ToParams returns the parameters specified by the configuration. The provided remote parameters are applied to all of
+// the providers that fetch IR from remote sources.
+func (c *ConjurePluginConfig) ToParams(remoteParams ...conjureplugin.RemoteParam) (conjureplugin.ConjureProjectParams, error) {
+	return c.ToParamsWithYAMLParams(nil, remoteParams...)
+}
+
+// ToParamsWithYAMLParams returns the parameters specified by the configuration. The provided YAML parameters are
+// applied to all of the providers that generate IR from YAML after the parameters specified by the configuration, and
+// the provided remote parameters are applied to all of the providers that fetch IR from remote sources.
+func (c *ConjurePluginConfig) ToParamsWithYAMLParams(yamlParams []conjureplugin.YAMLParam, remoteParams ...conjureplugin.RemoteParam) (conjureplugin.ConjureProjectParams, error) {
 	var keys []string
 	for k := range c.ProjectConfigs {
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
 
+	cfgYAMLParams, err := c.yamlParams()
+	if err != nil {
+		return conjureplugin.ConjureProjectParams{}, err
+	}
+	yamlParams = append(cfgYAMLParams, yamlParams...)
+	var expander extensionsExpander
 	params := make(map[string]conjureplugin.ConjureProjectParam)
 	for key, currConfig := range c.ProjectConfigs {
-		irProvider, err := (*IRLocatorConfig)(&currConfig.IRLocator).ToIRProvider()
+		projectYAMLParams, err := expander.projectYAMLParams(yamlParams, currConfig)
+		if err != nil {
+			return conjureplugin.ConjureProjectParams{}, errors.Wrapf(err, "invalid extensions for %s", key)
+		}
+		irProvider, err := (*IRLocatorConfig)(&currConfig.IRLocator).toIRProvider(projectYAMLParams, remoteParams)
 		if err != nil {
 			return conjureplugin.ConjureProjectParams{}, errors.Wrapf(err, "failed to convert configuration for %s to provider", key)
 		}
@@ -56,12 +80,17 @@ func (c *ConjurePluginConfig) ToParams() (conjureplugin.ConjureProjectParams, er
 		if currConfig.AcceptFuncs != nil {
 			acceptFuncsFlag = *currConfig.AcceptFuncs
 		}
+		definitionFilter, err := (*SingleConjureConfig)(&currConfig).definitionFilter()
+		if err != nil {
+			return conjureplugin.ConjureProjectParams{}, errors.Wrapf(err, "invalid definition selectors for %s", key)
+		}
 		params[key] = conjureplugin.ConjureProjectParam{
-			OutputDir:   currConfig.OutputDir,
-			IRProvider:  irProvider,
-			AcceptFuncs: acceptFuncsFlag,
-			Server:      currConfig.Server,
-			Publish:     publishVal,
+			OutputDir:        currConfig.OutputDir,
+			IRProvider:       irProvider,
+			AcceptFuncs:      acceptFuncsFlag,
+			Server:           currConfig.Server,
+			Publish:          publishVal,
+			DefinitionFilter: definitionFilter,
 		}
 	}
 	return conjureplugin.ConjureProjectParams{
@@ -70,12 +99,105 @@ func (c *ConjurePluginConfig) ToParams() (conjureplugin.ConjureProjectParams, er
 	}, nil
 }
 
+// yamlParams returns the parameters for the providers that generate IR from YAML specified by the configuration.
+func (c *ConjurePluginConfig) yamlParams() ([]conjureplugin.YAMLParam, error) {
+	compiler := conjureplugin.YAMLCompiler(c.YAMLCompiler)
+	switch compiler {
+	case "", conjureplugin.YAMLCompilerJava:
+	case conjureplugin.YAMLCompilerNative:
+		if !reflect.DeepEqual(c.ConjureCLI, v1.ConjureCLIConfig{}) {
+			return nil, errors.Errorf("conjure-cli cannot be specified when yaml-compiler is %s", conjureplugin.YAMLCompilerNative)
+		}
+		return []conjureplugin.YAMLParam{conjureplugin.YAMLCompilerParam(compiler)}, nil
+	default:
+		return nil, errors.Errorf("yaml-compiler must be %s or %s, but was %q", conjureplugin.YAMLCompilerJava, conjureplugin.YAMLCompilerNative, c.YAMLCompiler)
+	}
+
+	var params []conjureplugin.YAMLParam
+	if compiler != "" {
+		params = append(params, conjureplugin.YAMLCompilerParam(compiler))
+	}
+	if cliParams := (*ConjureCLIConfig)(&c.ConjureCLI).ToCLIParams(); len(cliParams) > 0 {
+		params = append(params, conjureplugin.YAMLCLIParams(cliParams...))
+	}
+	return params, nil
+}
+
+// Environment variables that override the values of ConjureCLIConfig. JVMOptionsEnvVar is split on whitespace.
+const (
+	JavaHomeEnvVar   = "GODEL_CONJURE_JAVA_HOME"
+	JVMOptionsEnvVar = "GODEL_CONJURE_JVM_OPTIONS"
+	CLIPathEnvVar    = "GODEL_CONJURE_CLI_PATH"
+	CLIVersionEnvVar = "GODEL_CONJURE_CLI_VERSION"
+)
+
+type ConjureCLIConfig v1.ConjureCLIConfig
+
+func ToConjureCLIConfig(in *ConjureCLIConfig) *v1.ConjureCLIConfig {
+	return (*v1.ConjureCLIConfig)(in)
+}
+
+// ToCLIParams returns the parameters for the Conjure CLI specified by the configuration. A value that is set in its
+// environment variable takes precedence over the value in the configuration so that the Java runtime and CLI can be
+// chosen per machine. Parameters are only returned for values that are specified.
+func (cfg *ConjureCLIConfig) ToCLIParams() []conjureircli.Param {
+	javaHome, jvmOptions, cliPath, cliVersion := cfg.JavaHome, cfg.JVMOptions, cfg.Path, cfg.Version
+	if val := os.Getenv(JavaHomeEnvVar); val != "" {
+		javaHome = val
+	}
+	if val := os.Getenv(JVMOptionsEnvVar); val != "" {
+		jvmOptions = strings.Fields(val)
+	}
+	if val := os.Getenv(CLIPathEnvVar); val != "" {
+		cliPath = val
+	}
+	if val := os.Getenv(CLIVersionEnvVar); val != "" {
+		cliVersion = val
+	}
+
+	var params []conjureircli.Param
+	if javaHome != "" {
+		params = append(params, conjureircli.JavaHomeParam(javaHome))
+	}
+	if len(jvmOptions) > 0 {
+		params = append(params, conjureircli.JVMOptionsParam(jvmOptions...))
+	}
+	if cliPath != "" {
+		params = append(params, conjureircli.CLIPathParam(cliPath))
+	}
+	if cliVersion != "" {
+		params = append(params, conjureircli.CLIVersionParam(cliVersion))
+	}
+	return params
+}
+
 type SingleConjureConfig v1.SingleConjureConfig
 
 func ToSingleConjureConfig(in *SingleConjureConfig) *v1.SingleConjureConfig {
 	return (*v1.SingleConjureConfig)(in)
 }
 
+// definitionFilter returns the filter specified by the include and exclude selectors of the configuration.
+func (cfg *SingleConjureConfig) definitionFilter() (conjureplugin.DefinitionFilter, error) {
+	var filter conjureplugin.DefinitionFilter
+	for _, curr := range []struct {
+		selectors []v1.DefinitionSelectorConfig
+		dst       *[]conjureplugin.DefinitionSelector
+	}{
+		{cfg.Include, &filter.Include},
+		{cfg.Exclude, &filter.Exclude},
+	} {
+		for _, selectorCfg := range curr.selectors {
+			selector := conjureplugin.DefinitionSelector(selectorCfg)
+			if err := selector.Validate(); err != nil {
+				return conjureplugin.DefinitionFilter{}, err
+			}
+			*curr.dst = append(*curr.dst, selector)
+		}
+	}
+	return filter, nil
+}
+
 type LocatorType v1.LocatorType
 
 type IRLocatorConfig v1.IRLocatorConfig
@@ -84,7 +206,36 @@ func ToIRLocatorConfig(in *IRLocatorConfig) *v1.IRLocatorConfig {
 	return (*v1.IRLocatorConfig)(in)
 }
 
-func (cfg *IRLocatorConfig) ToIRProvider() (conjureplugin.IRProvider, error) {
+// ToIRProvider returns the IRProvider specified by the configuration. The provided remote parameters are applied if the
+// provider fetches IR from remote sources.
+func (cfg *IRLocatorConfig) ToIRProvider(remoteParams ...conjureplugin.RemoteParam) (conjureplugin.IRProvider, error) {
+	return cfg.toIRProvider(nil, remoteParams)
+}
+
+// toIRProvider returns the IRProvider specified by the configuration. The provided YAML parameters are applied if the
+// provider generates IR from YAML.
+func (cfg *IRLocatorConfig) toIRProvider(yamlParams []conjureplugin.YAMLParam, remoteParams []conjureplugin.RemoteParam) (conjureplugin.IRProvider, error) {
+	provider, err := cfg.toUnpinnedIRProvider(yamlParams, remoteParams)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.SHA256 == "" {
+		return provider, nil
+	}
+	if !sha256Regexp.MatchString(cfg.SHA256) {
+		return nil, errors.Errorf("sha256 must be a hex-encoded SHA-256 digest, but was %q", cfg.SHA256)
+	}
+	return conjureplugin.NewPinnedIRProvider(provider, cfg.SHA256), nil
+}
+
+var sha256Regexp = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// toUnpinnedIRProvider returns the IRProvider specified by the configuration without verifying its output against the
+// SHA256 value of the configuration.
+func (cfg *IRLocatorConfig) toUnpinnedIRProvider(yamlParams []conjureplugin.YAMLParam, remoteParams []conjureplugin.RemoteParam) (conjureplugin.IRProvider, error) {
+	if len(cfg.Sources) > 0 {
+		return cfg.toMergedIRProvider(yamlParams, remoteParams)
+	}
 	if cfg.Locator == "" {
 		return nil, errors.Errorf("locator cannot be empty")
 	}
@@ -113,11 +264,61 @@ func (cfg *IRLocatorConfig) ToIRProvider() (conjureplugin.IRProvider, error) {
 		}
 	}
 
+	if cfg.Auth != nil && locatorType != v1.LocatorTypeRemote && locatorType != v1.LocatorTypeMaven && locatorType != v1.LocatorTypeArchive {
+		return nil, errors.Errorf("auth can only be specified for locators of type %s, %s or %s", v1.LocatorTypeRemote, v1.LocatorTypeMaven, v1.LocatorTypeArchive)
+	}
+	if cfg.Repository != "" && locatorType != v1.LocatorTypeMaven {
+		return nil, errors.Errorf("repository can only be specified for locators of type %s", v1.LocatorTypeMaven)
+	}
+	if cfg.Ref != "" && locatorType != v1.LocatorTypeGit {
+		return nil, errors.Errorf("ref can only be specified for locators of type %s", v1.LocatorTypeGit)
+	}
+	if cfg.Path != "" && locatorType != v1.LocatorTypeGit && locatorType != v1.LocatorTypeArchive {
+		return nil, errors.Errorf("path can only be specified for locators of type %s or %s", v1.LocatorTypeGit, v1.LocatorTypeArchive)
+	}
+	if (len(cfg.Args) > 0 || cfg.WorkingDir != "" || cfg.OutputFile != "" || cfg.Timeout != "") && locatorType != v1.LocatorTypeCommand {
+		return nil, errors.Errorf("args, working-dir, output-file and timeout can only be specified for locators of type %s", v1.LocatorTypeCommand)
+	}
+
 	switch locatorType {
 	case v1.LocatorTypeRemote:
-		return conjureplugin.NewHTTPIRProvider(cfg.Locator), nil
+		remoteParams, err := cfg.remoteParams(remoteParams)
+		if err != nil {
+			return nil, err
+		}
+		return conjureplugin.NewHTTPIRProvider(cfg.Locator, remoteParams...), nil
+	case v1.LocatorTypeMaven:
+		remoteParams, err := cfg.remoteParams(remoteParams)
+		if err != nil {
+			return nil, err
+		}
+		return conjureplugin.NewMavenIRProvider(cfg.Locator, cfg.Repository, remoteParams...)
+	case v1.LocatorTypeArchive:
+		remoteParams, err := cfg.remoteParams(remoteParams)
+		if err != nil {
+			return nil, err
+		}
+		return conjureplugin.NewArchiveIRProvider(cfg.Locator, cfg.Path, remoteParams...)
+	case v1.LocatorTypeCommand:
+		var timeout time.Duration
+		if cfg.Timeout != "" {
+			parsedTimeout, err := time.ParseDuration(cfg.Timeout)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid timeout %q", cfg.Timeout)
+			}
+			timeout = parsedTimeout
+		}
+		return conjureplugin.NewCommandIRProvider(conjureplugin.IRCommand{
+			Executable: cfg.Locator,
+			Args:       cfg.Args,
+			Dir:        cfg.WorkingDir,
+			OutputFile: cfg.OutputFile,
+			Timeout:    timeout,
+		})
+	case v1.LocatorTypeGit:
+		return conjureplugin.NewGitIRProvider(cfg.Locator, cfg.Ref, cfg.Path, yamlParams, remoteParams...)
 	case v1.LocatorTypeYAML:
-		return conjureplugin.NewLocalYAMLIRProvider(cfg.Locator), nil
+		return conjureplugin.NewLocalYAMLIRProvider(cfg.Locator, yamlParams...), nil
 	case v1.LocatorTypeIRFile:
 		return conjureplugin.NewLocalFileIRProvider(cfg.Locator), nil
 	default:
@@ -125,6 +326,160 @@ func (cfg *IRLocatorConfig) ToIRProvider() (conjureplugin.IRProvider, error) {
 	}
 }
 
+// toMergedIRProvider returns the IRProvider that merges the IR of the sources of the configuration. Every source is
+// verified against its own SHA256 value.
+func (cfg *IRLocatorConfig) toMergedIRProvider(yamlParams []conjureplugin.YAMLParam, remoteParams []conjureplugin.RemoteParam) (conjureplugin.IRProvider, error) {
+	if !reflect.DeepEqual(*cfg, IRLocatorConfig{Sources: cfg.Sources}) {
+		return nil, errors.Errorf("sources cannot be specified with any other value")
+	}
+	var providers []conjureplugin.IRProvider
+	for i, source := range cfg.Sources {
+		if len(source.Sources) > 0 {
+			return nil, errors.Errorf("source %d cannot specify sources", i)
+		}
+		provider, err := (*IRLocatorConfig)(&source).toIRProvider(yamlParams, remoteParams)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid source %d", i)
+		}
+		providers = append(providers, provider)
+	}
+	return conjureplugin.NewMergedIRProvider(providers...), nil
+}
+
+// remoteParams returns the provided parameters along with the parameters specified by the configuration. The
+// credentials are only resolved when the provider makes a remote request, so environment variables that are not set
+// only cause an error for the projects that need them.
+func (cfg *IRLocatorConfig) remoteParams(params []conjureplugin.RemoteParam) ([]conjureplugin.RemoteParam, error) {
+	params = append([]conjureplugin.RemoteParam(nil), params...)
+	if cfg.Auth != nil {
+		authCfg := (*RemoteAuthConfig)(cfg.Auth)
+		if err := authCfg.validate(); err != nil {
+			return nil, err
+		}
+		params = append(params, conjureplugin.RemoteAuthFuncParam(authCfg.identity(), authCfg.ToRemoteAuth))
+	}
+	return params, nil
+}
+
+type RemoteAuthConfig v1.RemoteAuthConfig
+
+func ToRemoteAuthConfig(in *RemoteAuthConfig) *v1.RemoteAuthConfig {
+	return (*v1.RemoteAuthConfig)(in)
+}
+
+// ToRemoteAuth resolves the credentials specified by the configuration. Returns an error if an environment variable
+// referenced by the configuration is not set. Errors only ever refer to the names of environment variables, never to
+// their values.
+func (cfg *RemoteAuthConfig) ToRemoteAuth() (conjureplugin.RemoteAuth, error) {
+	if err := cfg.validate(); err != nil {
+		return conjureplugin.RemoteAuth{}, err
+	}
+	var auth conjureplugin.RemoteAuth
+	for _, currEnv := range []struct {
+		name string
+		dst  *string
+	}{
+		{cfg.TokenEnv, &auth.BearerToken},
+		{cfg.UsernameEnv, &auth.Username},
+		{cfg.PasswordEnv, &auth.Password},
+	} {
+		if currEnv.name == "" {
+			continue
+		}
+		val, ok := os.LookupEnv(currEnv.name)
+		if !ok || val == "" {
+			return conjureplugin.RemoteAuth{}, errors.Errorf("environment variable %s is not set", currEnv.name)
+		}
+		*currEnv.dst = val
+	}
+	if len(cfg.Headers) > 0 {
+		auth.Headers = make(map[string]string, len(cfg.Headers))
+		for k, v := range cfg.Headers {
+			expanded, err := expandHeaderValue(v)
+			if err != nil {
+				return conjureplugin.RemoteAuth{}, errors.Wrapf(err, "invalid value for header %s", k)
+			}
+			auth.Headers[k] = expanded
+		}
+	}
+	switch {
+	case cfg.NetrcFile != "":
+		auth.NetrcPath = cfg.NetrcFile
+	case cfg.Netrc:
+		netrcPath, err := conjureplugin.DefaultNetrcPath()
+		if err != nil {
+			return conjureplugin.RemoteAuth{}, err
+		}
+		auth.NetrcPath = netrcPath
+	}
+	return auth, nil
+}
+
+// validate returns an error if the configuration is invalid regardless of the values of the environment variables that
+// it references.
+func (cfg *RemoteAuthConfig) validate() error {
+	if cfg.TokenEnv != "" && (cfg.UsernameEnv != "" || cfg.PasswordEnv != "") {
+		return errors.Errorf("token-env and username-env/password-env cannot both be specified")
+	}
+	for k := range cfg.Headers {
+		if http.CanonicalHeaderKey(k) == "Authorization" && (cfg.TokenEnv != "" || cfg.UsernameEnv != "" || cfg.PasswordEnv != "" || cfg.Netrc || cfg.NetrcFile != "") {
+			return errors.Errorf("headers cannot specify Authorization when token-env, username-env, password-env, netrc or netrc-file is specified")
+		}
+	}
+	return nil
+}
+
+// identity returns a description of the credentials specified by the configuration that identifies them by the names of
+// the environment variables and files from which they are read, so it never includes any secrets.
+func (cfg *RemoteAuthConfig) identity() string {
+	var parts []string
+	for _, curr := range []struct {
+		key, value string
+	}{
+		{"token-env", cfg.TokenEnv},
+		{"username-env", cfg.UsernameEnv},
+		{"password-env", cfg.PasswordEnv},
+		{"netrc-file", cfg.NetrcFile},
+	} {
+		if curr.value != "" {
+			parts = append(parts, curr.key+"="+curr.value)
+		}
+	}
+	if cfg.Netrc {
+		parts = append(parts, "netrc=true")
+	}
+	var headerNames []string
+	for k, v := range cfg.Headers {
+		// the values of headers are identified by the environment variables that they reference, if any
+		headerNames = append(headerNames, http.CanonicalHeaderKey(k)+"="+strings.Join(envVarRefRegexp.FindAllString(v, -1), ","))
+	}
+	sort.Strings(headerNames)
+	if len(headerNames) > 0 {
+		parts = append(parts, "headers="+strings.Join(headerNames, ";"))
+	}
+	return strings.Join(parts, " ")
+}
+
+var envVarRefRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandHeaderValue returns the provided value with every reference of the form ${VAR} replaced by the value of the
+// environment variable VAR. Returns an error if a referenced environment variable is not set.
+func expandHeaderValue(v string) (string, error) {
+	var unset []string
+	expanded := envVarRefRegexp.ReplaceAllStringFunc(v, func(ref string) string {
+		name := envVarRefRegexp.FindStringSubmatch(ref)[1]
+		val, ok := os.LookupEnv(name)
+		if !ok || val == "" {
+			unset = append(unset, name)
+		}
+		return val
+	})
+	if len(unset) > 0 {
+		return "", errors.Errorf("environment variable %s is not set", unset[0])
+	}
+	return expanded, nil
+}
+
 func ReadConfigFromFile(f string) (ConjurePluginConfig, error) {
 	bytes, err := ioutil.ReadFile(f)
 	if err != nil {
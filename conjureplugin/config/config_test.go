@@ -15,6 +15,10 @@
 package config_test
 
 import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
@@ -239,6 +243,232 @@ projects:
 				},
 			},
 		},
+		{
+			`
+projects:
+ project:
+   output-dir: outputDir
+   ir-locator:
+     type: remote
+     locator: https://artifactory.domain.com/ir.json
+     auth:
+       token-env: ARTIFACTORY_TOKEN
+       headers:
+         X-Api-Key: ${API_KEY}
+       netrc: true
+`,
+			config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project": {
+						OutputDir: "outputDir",
+						IRLocator: v1.IRLocatorConfig{
+							Type:    v1.LocatorTypeRemote,
+							Locator: "https://artifactory.domain.com/ir.json",
+							Auth: &v1.RemoteAuthConfig{
+								TokenEnv: "ARTIFACTORY_TOKEN",
+								Headers: map[string]string{
+									"X-Api-Key": "${API_KEY}",
+								},
+								Netrc: true,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			`
+projects:
+ project:
+   output-dir: outputDir
+   ir-locator:
+     type: maven
+     locator: com.palantir.spec:health-api:latest
+     repository: https://artifactory.domain.com/artifactory/conjure-release
+`,
+			config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project": {
+						OutputDir: "outputDir",
+						IRLocator: v1.IRLocatorConfig{
+							Type:       v1.LocatorTypeMaven,
+							Locator:    "com.palantir.spec:health-api:latest",
+							Repository: "https://artifactory.domain.com/artifactory/conjure-release",
+						},
+					},
+				},
+			},
+		},
+		{
+			`
+projects:
+ project:
+   output-dir: outputDir
+   ir-locator:
+     type: git
+     locator: https://github.com/palantir/health-api.git
+     ref: v3.2.0
+     path: health-api/src/main/conjure
+`,
+			config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project": {
+						OutputDir: "outputDir",
+						IRLocator: v1.IRLocatorConfig{
+							Type:    v1.LocatorTypeGit,
+							Locator: "https://github.com/palantir/health-api.git",
+							Ref:     "v3.2.0",
+							Path:    "health-api/src/main/conjure",
+						},
+					},
+				},
+			},
+		},
+		{
+			`
+projects:
+ project:
+   output-dir: outputDir
+   ir-locator:
+     type: archive
+     locator: https://foo.com/health-api-3.2.0.jar
+     path: conjure/*.conjure.json
+`,
+			config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project": {
+						OutputDir: "outputDir",
+						IRLocator: v1.IRLocatorConfig{
+							Type:    v1.LocatorTypeArchive,
+							Locator: "https://foo.com/health-api-3.2.0.jar",
+							Path:    "conjure/*.conjure.json",
+						},
+					},
+				},
+			},
+		},
+		{
+			`
+projects:
+ project:
+   output-dir: outputDir
+   ir-locator:
+     type: command
+     locator: ./gradlew
+     args: [exportConjureIr, --quiet]
+     working-dir: exporter
+     output-file: build/api.conjure.json
+     timeout: 2m
+`,
+			config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project": {
+						OutputDir: "outputDir",
+						IRLocator: v1.IRLocatorConfig{
+							Type:       v1.LocatorTypeCommand,
+							Locator:    "./gradlew",
+							Args:       []string{"exportConjureIr", "--quiet"},
+							WorkingDir: "exporter",
+							OutputFile: "build/api.conjure.json",
+							Timeout:    "2m",
+						},
+					},
+				},
+			},
+		},
+		{
+			`
+projects:
+ project:
+   output-dir: outputDir
+   ir-locator:
+     - https://foo.com/ir.json
+     - type: yaml
+       locator: local/yaml-dir
+`,
+			config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project": {
+						OutputDir: "outputDir",
+						IRLocator: v1.IRLocatorConfig{
+							Sources: []v1.IRLocatorConfig{
+								{
+									Type:    v1.LocatorTypeAuto,
+									Locator: "https://foo.com/ir.json",
+								},
+								{
+									Type:    v1.LocatorTypeYAML,
+									Locator: "local/yaml-dir",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			`
+conjure-cli:
+  java-home: /opt/jdk-11
+  jvm-options:
+    - -Xmx1g
+  path: /usr/local/bin/conjure
+  version: 4.40.0
+projects:
+ project:
+   output-dir: outputDir
+   ir-locator: local/yaml-dir
+`,
+			config.ConjurePluginConfig{
+				ConjureCLI: v1.ConjureCLIConfig{
+					JavaHome:   "/opt/jdk-11",
+					JVMOptions: []string{"-Xmx1g"},
+					Path:       "/usr/local/bin/conjure",
+					Version:    "4.40.0",
+				},
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project": {
+						OutputDir: "outputDir",
+						IRLocator: v1.IRLocatorConfig{
+							Type:    v1.LocatorTypeAuto,
+							Locator: "local/yaml-dir",
+						},
+					},
+				},
+			},
+		},
+		{
+			`
+projects:
+ project:
+   output-dir: outputDir
+   ir-locator: local/yaml-dir
+   include:
+     - service: FooService
+     - package: com.palantir.bar.*
+       type: Bar*
+   exclude:
+     - package: com.palantir.bar.internal
+`,
+			config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project": {
+						OutputDir: "outputDir",
+						IRLocator: v1.IRLocatorConfig{
+							Type:    v1.LocatorTypeAuto,
+							Locator: "local/yaml-dir",
+						},
+						Include: []v1.DefinitionSelectorConfig{
+							{Service: "FooService"},
+							{Package: "com.palantir.bar.*", Type: "Bar*"},
+						},
+						Exclude: []v1.DefinitionSelectorConfig{
+							{Package: "com.palantir.bar.internal"},
+						},
+					},
+				},
+			},
+		},
 	} {
 		var got config.ConjurePluginConfig
 		err := yaml.Unmarshal([]byte(tc.in), &got)
@@ -248,6 +478,11 @@ projects:
 }
 
 func TestConjurePluginConfigToParam(t *testing.T) {
+	require.NoError(t, os.Setenv("TEST_CONJURE_PRODUCT_OWNER", "api-team"))
+	defer func() {
+		_ = os.Unsetenv("TEST_CONJURE_PRODUCT_OWNER")
+	}()
+
 	for i, tc := range []struct {
 		in   config.ConjurePluginConfig
 		want conjureplugin.ConjureProjectParams
@@ -355,6 +590,117 @@ func TestConjurePluginConfigToParam(t *testing.T) {
 				},
 			},
 		},
+		{
+			config.ConjurePluginConfig{
+				YAMLCompiler: "native",
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project-1": {
+						OutputDir: "outputDir",
+						IRLocator: v1.IRLocatorConfig{
+							Type:    v1.LocatorTypeAuto,
+							Locator: "input.yml",
+						},
+					},
+				},
+			},
+			conjureplugin.ConjureProjectParams{
+				SortedKeys: []string{
+					"project-1",
+				},
+				Params: map[string]conjureplugin.ConjureProjectParam{
+					"project-1": {
+						OutputDir:   "outputDir",
+						IRProvider:  conjureplugin.NewLocalYAMLIRProvider("input.yml", conjureplugin.YAMLCompilerParam(conjureplugin.YAMLCompilerNative)),
+						Publish:     true,
+						AcceptFuncs: true,
+					},
+				},
+			},
+		},
+		{
+			config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project-1": {
+						OutputDir: "outputDir",
+						IRLocator: v1.IRLocatorConfig{
+							Type:    v1.LocatorTypeAuto,
+							Locator: "input.yml",
+						},
+						Extensions: map[string]interface{}{
+							"product-owner": "${env.TEST_CONJURE_PRODUCT_OWNER}",
+							"owner-note":    "$TEST_CONJURE_PRODUCT_OWNER owns ${TEST_CONJURE_PRODUCT_OWNER}",
+							"recommended-product-dependencies": []interface{}{
+								map[interface{}]interface{}{
+									"product-name":    "asset-server",
+									"minimum-version": "2.78.0",
+								},
+							},
+						},
+					},
+				},
+			},
+			conjureplugin.ConjureProjectParams{
+				SortedKeys: []string{
+					"project-1",
+				},
+				Params: map[string]conjureplugin.ConjureProjectParam{
+					"project-1": {
+						OutputDir: "outputDir",
+						IRProvider: conjureplugin.NewLocalYAMLIRProvider("input.yml", conjureplugin.YAMLExtensionsParam(map[string]interface{}{
+							"product-owner": "api-team",
+							"owner-note":    "$TEST_CONJURE_PRODUCT_OWNER owns ${TEST_CONJURE_PRODUCT_OWNER}",
+							"recommended-product-dependencies": []interface{}{
+								map[string]interface{}{
+									"product-name":    "asset-server",
+									"minimum-version": "2.78.0",
+								},
+							},
+						})),
+						Publish:     true,
+						AcceptFuncs: true,
+					},
+				},
+			},
+		},
+		{
+			config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project-1": {
+						OutputDir: "outputDir",
+						IRLocator: v1.IRLocatorConfig{
+							Type:    v1.LocatorTypeIRFile,
+							Locator: "input.json",
+						},
+						Include: []v1.DefinitionSelectorConfig{
+							{Service: "FooService"},
+						},
+						Exclude: []v1.DefinitionSelectorConfig{
+							{Package: "com.palantir.internal", Type: "*"},
+						},
+					},
+				},
+			},
+			conjureplugin.ConjureProjectParams{
+				SortedKeys: []string{
+					"project-1",
+				},
+				Params: map[string]conjureplugin.ConjureProjectParam{
+					"project-1": {
+						OutputDir:   "outputDir",
+						IRProvider:  conjureplugin.NewLocalFileIRProvider("input.json"),
+						AcceptFuncs: true,
+						DefinitionFilter: conjureplugin.DefinitionFilter{
+							Include: []conjureplugin.DefinitionSelector{
+								{Service: "FooService"},
+							},
+							Exclude: []conjureplugin.DefinitionSelector{
+								{Package: "com.palantir.internal", Type: "*"},
+							},
+						},
+					},
+				},
+			},
+		},
 	} {
 		got, err := tc.in.ToParams()
 		require.NoError(t, err, "Case %d", i)
@@ -362,6 +708,206 @@ func TestConjurePluginConfigToParam(t *testing.T) {
 	}
 }
 
+func TestToParamsInvalidYAMLCompiler(t *testing.T) {
+	for i, tc := range []struct {
+		in      config.ConjurePluginConfig
+		wantErr string
+	}{
+		{
+			config.ConjurePluginConfig{
+				YAMLCompiler: "javascript",
+			},
+			`yaml-compiler must be java or native, but was "javascript"`,
+		},
+		{
+			config.ConjurePluginConfig{
+				YAMLCompiler: "native",
+				ConjureCLI: v1.ConjureCLIConfig{
+					Version: "4.40.0",
+				},
+			},
+			`conjure-cli cannot be specified when yaml-compiler is native`,
+		},
+		{
+			config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project-1": {
+						IRLocator: v1.IRLocatorConfig{
+							Locator: "input.yml",
+						},
+						Extensions: map[string]interface{}{
+							"repository": "${git.branch}",
+						},
+					},
+				},
+			},
+			`invalid extensions for project-1: failed to expand ${git.branch}: unknown Git value "branch": must be one of version, commit or url`,
+		},
+		{
+			config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project-1": {
+						IRLocator: v1.IRLocatorConfig{
+							Locator: "input.yml",
+						},
+						Extensions: map[string]interface{}{
+							"build-id": "${env.TEST_CONJURE_UNSET_BUILD_ID}",
+						},
+					},
+				},
+			},
+			`invalid extensions for project-1: failed to expand ${env.TEST_CONJURE_UNSET_BUILD_ID}: environment variable TEST_CONJURE_UNSET_BUILD_ID is not set`,
+		},
+		{
+			config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project-1": {
+						IRLocator: v1.IRLocatorConfig{
+							Locator: "input.yml",
+						},
+						Include: []v1.DefinitionSelectorConfig{
+							{Type: "Foo", Service: "FooService"},
+						},
+					},
+				},
+			},
+			`invalid definition selectors for project-1: selector {type=Foo,service=FooService} cannot specify both type and service`,
+		},
+		{
+			config.ConjurePluginConfig{
+				ProjectConfigs: map[string]v1.SingleConjureConfig{
+					"project-1": {
+						IRLocator: v1.IRLocatorConfig{
+							Locator: "input.yml",
+						},
+						Exclude: []v1.DefinitionSelectorConfig{
+							{},
+						},
+					},
+				},
+			},
+			`invalid definition selectors for project-1: selector must specify at least one of package, type or service`,
+		},
+	} {
+		_, err := tc.in.ToParams()
+		assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+	}
+}
+
+func TestConjureCLIConfigToCLIParams(t *testing.T) {
+	cfg := config.ConjureCLIConfig{
+		JavaHome: "/opt/jdk-11",
+	}
+	assert.Len(t, cfg.ToCLIParams(), 1)
+
+	require.NoError(t, os.Setenv(config.JVMOptionsEnvVar, "-Xmx1g -Dfoo=bar"))
+	defer func() {
+		_ = os.Unsetenv(config.JVMOptionsEnvVar)
+	}()
+	assert.Len(t, cfg.ToCLIParams(), 2)
+	assert.Len(t, (&config.ConjureCLIConfig{}).ToCLIParams(), 1)
+}
+
+func TestRemoteAuthConfigToRemoteAuth(t *testing.T) {
+	for k, v := range map[string]string{
+		"TEST_CONJURE_TOKEN":   "token-value",
+		"TEST_CONJURE_API_KEY": "api-key-value",
+	} {
+		require.NoError(t, os.Setenv(k, v))
+		defer func(k string) {
+			_ = os.Unsetenv(k)
+		}(k)
+	}
+
+	cfg := config.RemoteAuthConfig{
+		TokenEnv: "TEST_CONJURE_TOKEN",
+		Headers: map[string]string{
+			"X-Api-Key": "${TEST_CONJURE_API_KEY}",
+		},
+		NetrcFile: "custom.netrc",
+	}
+	got, err := cfg.ToRemoteAuth()
+	require.NoError(t, err)
+	assert.Equal(t, conjureplugin.RemoteAuth{
+		BearerToken: "token-value",
+		Headers: map[string]string{
+			"X-Api-Key": "api-key-value",
+		},
+		NetrcPath: "custom.netrc",
+	}, got)
+
+	cfg = config.RemoteAuthConfig{
+		UsernameEnv: "TEST_CONJURE_TOKEN",
+		PasswordEnv: "TEST_CONJURE_UNSET_PASSWORD",
+	}
+	_, err = cfg.ToRemoteAuth()
+	assert.EqualError(t, err, "environment variable TEST_CONJURE_UNSET_PASSWORD is not set")
+
+	cfg = config.RemoteAuthConfig{
+		Headers: map[string]string{
+			"X-Api-Key": "${TEST_CONJURE_UNSET_API_KEY}",
+		},
+	}
+	_, err = cfg.ToRemoteAuth()
+	assert.EqualError(t, err, "invalid value for header X-Api-Key: environment variable TEST_CONJURE_UNSET_API_KEY is not set")
+
+	cfg = config.RemoteAuthConfig{
+		Headers: map[string]string{
+			"X-Api-Key": "$TEST_CONJURE_API_KEY",
+		},
+	}
+	got, err = cfg.ToRemoteAuth()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"X-Api-Key": "$TEST_CONJURE_API_KEY"}, got.Headers)
+
+	cfg = config.RemoteAuthConfig{
+		Netrc: true,
+		Headers: map[string]string{
+			"authorization": "Bearer ${TEST_CONJURE_API_KEY}",
+		},
+	}
+	_, err = cfg.ToRemoteAuth()
+	assert.EqualError(t, err, "headers cannot specify Authorization when token-env, username-env, password-env, netrc or netrc-file is specified")
+}
+
+func TestToParamsUnsetCredentialsOnlyAffectTheirProject(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, "go.mod"), []byte("module github.com/palantir/test\n"), 0644))
+	irPath := filepath.Join(projectDir, "ir.json")
+	require.NoError(t, ioutil.WriteFile(irPath, []byte(`{"version":1,"errors":[],"types":[],"services":[],"extensions":{}}`), 0644))
+
+	cfg := config.ConjurePluginConfig{
+		ProjectConfigs: map[string]v1.SingleConjureConfig{
+			"local": {
+				OutputDir: "conjure",
+				IRLocator: v1.IRLocatorConfig{
+					Type:    v1.LocatorTypeIRFile,
+					Locator: irPath,
+				},
+			},
+			"remote": {
+				OutputDir: "conjure",
+				IRLocator: v1.IRLocatorConfig{
+					Type:    v1.LocatorTypeRemote,
+					Locator: "https://artifactory.domain.com/ir.json",
+					Auth: &v1.RemoteAuthConfig{
+						TokenEnv: "TEST_CONJURE_UNSET_TOKEN",
+					},
+				},
+			},
+		},
+	}
+	params, err := cfg.ToParams()
+	require.NoError(t, err)
+
+	// the project whose credentials are not set can be excluded
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, conjureplugin.ProjectsParam([]string{"local"}, nil)))
+
+	// the credentials are resolved when the project is run
+	err = conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, conjureplugin.ProjectsParam([]string{"remote"}, nil))
+	assert.EqualError(t, err, "failed to get IR for remote: failed to resolve credentials for remote source https://artifactory.domain.com/ir.json: environment variable TEST_CONJURE_UNSET_TOKEN is not set")
+}
+
 func boolPtr(in bool) *bool {
 	return &in
 }
@@ -0,0 +1,185 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	gitversioner "github.com/palantir/distgo/projectversioner/git"
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	v1 "github.com/palantir/godel-conjure-plugin/v6/conjureplugin/config/internal/v1"
+	"github.com/pkg/errors"
+)
+
+const (
+	envValuePrefix = "env"
+	gitValuePrefix = "git"
+)
+
+// extensionRefRegexp matches the references that are expanded in the values of extensions.
+var extensionRefRegexp = regexp.MustCompile(`\$\{(` + envValuePrefix + `|` + gitValuePrefix + `)\.([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// extensionsExpander expands the references in the values of extensions. The values of Git references are computed for
+// the Git repository of the working directory the first time that they are referenced.
+type extensionsExpander struct {
+	gitValues map[string]string
+}
+
+// projectYAMLParams returns the provided YAML parameters along with a parameter that sets the extensions of the
+// provided project configuration, if any.
+func (e *extensionsExpander) projectYAMLParams(yamlParams []conjureplugin.YAMLParam, projectCfg v1.SingleConjureConfig) ([]conjureplugin.YAMLParam, error) {
+	if len(projectCfg.Extensions) == 0 {
+		return yamlParams, nil
+	}
+	extensions, err := e.expand(projectCfg.Extensions)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]conjureplugin.YAMLParam(nil), yamlParams...), conjureplugin.YAMLExtensionsParam(extensions)), nil
+}
+
+// expand returns a copy of the provided extensions in which the references in all string values are expanded. Maps
+// are converted to map[string]interface{} so that the result can be marshaled as JSON.
+func (e *extensionsExpander) expand(extensions map[string]interface{}) (map[string]interface{}, error) {
+	if len(extensions) == 0 {
+		return nil, nil
+	}
+	expanded, err := e.expandValue(extensions)
+	if err != nil {
+		return nil, err
+	}
+	return expanded.(map[string]interface{}), nil
+}
+
+func (e *extensionsExpander) expandValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return e.expandString(v)
+	case []interface{}:
+		expanded := make([]interface{}, len(v))
+		for i, elem := range v {
+			expandedElem, err := e.expandValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			expanded[i] = expandedElem
+		}
+		return expanded, nil
+	case map[string]interface{}:
+		expanded := make(map[string]interface{}, len(v))
+		for k, elem := range v {
+			expandedElem, err := e.expandValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			expanded[k] = expandedElem
+		}
+		return expanded, nil
+	case map[interface{}]interface{}:
+		expanded := make(map[string]interface{}, len(v))
+		for k, elem := range v {
+			expandedElem, err := e.expandValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			expanded[fmt.Sprint(k)] = expandedElem
+		}
+		return expanded, nil
+	default:
+		return value, nil
+	}
+}
+
+// expandString expands the references of the form "${env.NAME}" and "${git.NAME}" in the provided string. Any other
+// text, including other references of the form "$NAME" or "${NAME}", is left as is.
+func (e *extensionsExpander) expandString(in string) (string, error) {
+	var expandErr error
+	out := extensionRefRegexp.ReplaceAllStringFunc(in, func(ref string) string {
+		if expandErr != nil {
+			return ref
+		}
+		submatches := extensionRefRegexp.FindStringSubmatch(ref)
+		var val string
+		var err error
+		switch submatches[1] {
+		case envValuePrefix:
+			var ok bool
+			if val, ok = os.LookupEnv(submatches[2]); !ok {
+				err = errors.Errorf("environment variable %s is not set", submatches[2])
+			}
+		case gitValuePrefix:
+			val, err = e.gitValue(submatches[2])
+		}
+		if err != nil {
+			expandErr = errors.Wrapf(err, "failed to expand %s", ref)
+		}
+		return val
+	})
+	if expandErr != nil {
+		return "", expandErr
+	}
+	return out, nil
+}
+
+// gitValue returns the value of the Git reference with the provided name.
+func (e *extensionsExpander) gitValue(name string) (string, error) {
+	if val, ok := e.gitValues[name]; ok {
+		return val, nil
+	}
+	var val string
+	var err error
+	switch name {
+	case "version":
+		val, err = gitversioner.New().ProjectVersion(".")
+	case "commit":
+		val, err = gitOutput("rev-parse", "HEAD")
+	case "url":
+		val, err = gitOutput("config", "--get", "remote.origin.url")
+		val = redactGitURL(val)
+	default:
+		return "", errors.Errorf("unknown Git value %q: must be one of version, commit or url", name)
+	}
+	if err != nil {
+		return "", err
+	}
+	if e.gitValues == nil {
+		e.gitValues = make(map[string]string)
+	}
+	e.gitValues[name] = val
+	return val, nil
+}
+
+func gitOutput(args ...string) (string, error) {
+	output, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "git %s failed", args[0])
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// redactGitURL removes any credentials from the provided remote URL so that they are never written to the IR.
+func redactGitURL(remoteURL string) string {
+	parsedURL, err := url.Parse(remoteURL)
+	if err != nil || parsedURL.User == nil {
+		return remoteURL
+	}
+	parsedURL.User = nil
+	return parsedURL.String()
+}
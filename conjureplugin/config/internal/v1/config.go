@@ -22,7 +22,25 @@ import (
 
 type ConjurePluginConfig struct {
 	versionedconfig.ConfigWithVersion `yaml:",inline,omitempty"`
-	ProjectConfigs                    map[string]SingleConjureConfig `yaml:"projects"`
+	// YAMLCompiler is the compiler used to generate IR from Conjure YAML: either "java" (the default), which runs the
+	// bundled Conjure CLI, or "native", which uses the Go implementation of the compiler and does not require Java.
+	YAMLCompiler string `yaml:"yaml-compiler,omitempty"`
+	// ConjureCLI configures the Conjure CLI that is run by the "java" YAML compiler.
+	ConjureCLI     ConjureCLIConfig               `yaml:"conjure-cli,omitempty"`
+	ProjectConfigs map[string]SingleConjureConfig `yaml:"projects"`
+}
+
+// ConjureCLIConfig is configuration for the Conjure CLI that generates IR from YAML.
+type ConjureCLIConfig struct {
+	// JavaHome is the Java home directory of the Java runtime that runs the CLI. If unspecified, the Java runtime is
+	// determined by the environment.
+	JavaHome string `yaml:"java-home,omitempty"`
+	// JVMOptions are the options provided to the JVM that runs the CLI.
+	JVMOptions []string `yaml:"jvm-options,omitempty"`
+	// Path is the path to a Conjure CLI executable that is run instead of the bundled CLI.
+	Path string `yaml:"path,omitempty"`
+	// Version is the version that the CLI must have. The version of the CLI is checked before it compiles any YAML.
+	Version string `yaml:"version,omitempty"`
 }
 
 type SingleConjureConfig struct {
@@ -37,23 +55,98 @@ type SingleConjureConfig struct {
 	// AcceptFuncs indicates if we will generate lambda based visitor code.
 	// Currently this is behind a feature flag and is subject to change.
 	AcceptFuncs *bool `yaml:"accept-funcs,omitempty"`
+	// Extensions are the extensions of the IR generated from YAML. References of the form "${env.VAR}" in string values
+	// are expanded to the value of the environment variable VAR, which must be set, and the references "${git.version}",
+	// "${git.commit}" and "${git.url}" are expanded to the version, commit and remote URL of the Git repository of the
+	// project. Any other text is left as is.
+	Extensions map[string]interface{} `yaml:"extensions,omitempty"`
+	// Include selects the types, errors and services of the IR that are generated. If specified, only the definitions
+	// that match at least one of the selectors and the types that they reference are generated.
+	Include []DefinitionSelectorConfig `yaml:"include,omitempty"`
+	// Exclude specifies the types, errors and services of the IR that are not generated unless they are referenced by a
+	// definition that is generated.
+	Exclude []DefinitionSelectorConfig `yaml:"exclude,omitempty"`
+}
+
+// DefinitionSelectorConfig selects definitions of the IR of a project. Every value that is specified is a glob pattern
+// that must match the corresponding part of the name of a definition: Package matches the package of types, errors and
+// services, Type matches the name of types and errors and Service matches the name of services.
+type DefinitionSelectorConfig struct {
+	Package string `yaml:"package,omitempty"`
+	Type    string `yaml:"type,omitempty"`
+	Service string `yaml:"service,omitempty"`
 }
 
 type LocatorType string
 
 const (
-	LocatorTypeAuto   = LocatorType("auto")
-	LocatorTypeRemote = LocatorType("remote")
-	LocatorTypeYAML   = LocatorType("yaml")
-	LocatorTypeIRFile = LocatorType("ir-file")
+	LocatorTypeAuto    = LocatorType("auto")
+	LocatorTypeRemote  = LocatorType("remote")
+	LocatorTypeYAML    = LocatorType("yaml")
+	LocatorTypeIRFile  = LocatorType("ir-file")
+	LocatorTypeMaven   = LocatorType("maven")
+	LocatorTypeGit     = LocatorType("git")
+	LocatorTypeArchive = LocatorType("archive")
+	LocatorTypeCommand = LocatorType("command")
 )
 
-// IRLocatorConfig is configuration that specifies a locator. It can be specified as a YAML string or as a full YAML
-// object. If it is specified as a YAML string, then the string is used as the value of "Locator" and LocatorTypeAuto is
-// used as the value of the type.
+// IRLocatorConfig is configuration that specifies a locator. It can be specified as a YAML string, as a full YAML
+// object or as a YAML list. If it is specified as a YAML string, then the string is used as the value of "Locator" and
+// LocatorTypeAuto is used as the value of the type. If it is specified as a YAML list, then the elements of the list
+// are used as the value of "Sources".
 type IRLocatorConfig struct {
 	Type    LocatorType `yaml:"type"`
 	Locator string      `yaml:"locator"`
+	// Repository is the base URL of the Maven repository from which the IR is resolved. Only valid for Maven locators,
+	// for which the locator is a coordinate of the form "group:artifact:version".
+	Repository string `yaml:"repository,omitempty"`
+	// Ref is the tag, branch or commit of the Git repository from which the IR is resolved. Only valid for Git locators,
+	// for which the locator is the URL or path of the repository.
+	Ref string `yaml:"ref,omitempty"`
+	// Path is the path within the Git repository of the Conjure YAML file, the directory of Conjure YAML files or the IR
+	// file for Git locators, and the path or glob pattern of the IR file within the archive for archive locators. Only
+	// valid for Git and archive locators.
+	Path string `yaml:"path,omitempty"`
+	// Args are the arguments provided to the executable of a command locator, for which the locator is the executable.
+	Args []string `yaml:"args,omitempty"`
+	// WorkingDir is the working directory of the command. Only valid for command locators.
+	WorkingDir string `yaml:"working-dir,omitempty"`
+	// OutputFile is the path of the file to which the command writes the IR, relative to the working directory of the
+	// command. If unspecified, the IR is read from the standard output of the command. Only valid for command locators.
+	OutputFile string `yaml:"output-file,omitempty"`
+	// Timeout is the maximum duration of the command as a Go duration string such as "30s". Only valid for command
+	// locators.
+	Timeout string `yaml:"timeout,omitempty"`
+	// SHA256 is the expected hex-encoded SHA-256 digest of the IR provided by the locator. If specified, it is an error
+	// for the provided IR to have a different digest.
+	SHA256 string `yaml:"sha256,omitempty"`
+	// Auth specifies the credentials used to fetch IR from a remote locator. Only valid for remote, Maven and archive
+	// locators.
+	Auth *RemoteAuthConfig `yaml:"auth,omitempty"`
+	// Sources are the locators whose IR is merged into a single definition. If specified, no other value can be
+	// specified for this locator.
+	Sources []IRLocatorConfig `yaml:"sources,omitempty"`
+}
+
+// RemoteAuthConfig specifies how requests for remote IR are authenticated. Secrets are never specified directly in
+// configuration: they are read from the environment variables named by this configuration or from a netrc file.
+type RemoteAuthConfig struct {
+	// TokenEnv is the name of the environment variable that contains the bearer token sent with the request.
+	TokenEnv string `yaml:"token-env,omitempty"`
+	// UsernameEnv is the name of the environment variable that contains the username used for basic authentication.
+	UsernameEnv string `yaml:"username-env,omitempty"`
+	// PasswordEnv is the name of the environment variable that contains the password used for basic authentication.
+	PasswordEnv string `yaml:"password-env,omitempty"`
+	// Headers are additional headers sent with the request. Environment variable references of the form ${VAR} in the
+	// values are expanded, and it is an error for a referenced variable to not be set. Headers cannot specify
+	// Authorization if any other credentials are configured.
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// Netrc specifies that credentials for the remote host should be read from the netrc file if no other credentials
+	// are configured. The file specified by the NETRC environment variable is used if it is set, and ~/.netrc is used
+	// otherwise.
+	Netrc bool `yaml:"netrc,omitempty"`
+	// NetrcFile is the path to the netrc file that should be used. Implies Netrc.
+	NetrcFile string `yaml:"netrc-file,omitempty"`
 }
 
 func (cfg *IRLocatorConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
@@ -65,6 +158,13 @@ func (cfg *IRLocatorConfig) UnmarshalYAML(unmarshal func(interface{}) error) err
 		return nil
 	}
 
+	var sliceInput []IRLocatorConfig
+	if err := unmarshal(&sliceInput); err == nil && len(sliceInput) > 0 {
+		// input was specified as a list: use elements as sources
+		cfg.Sources = sliceInput
+		return nil
+	}
+
 	type irLocatorConfigAlias IRLocatorConfig
 	var unmarshaledCfg irLocatorConfigAlias
 	if err := unmarshal(&unmarshaledCfg); err != nil {
@@ -0,0 +1,297 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	v1 "github.com/palantir/godel-conjure-plugin/v6/conjureplugin/config/internal/v1"
+	"github.com/pkg/errors"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// CurrentPins returns a map from project key to the hex-encoded SHA-256 digests of the IR currently provided by the
+// sources of the locator of the project. A locator that does not specify sources has a single source. Digests are
+// computed for sources whose IR is not generated from YAML and for sources that are already pinned: the digest of other
+// sources is the empty string. Projects without any such sources are omitted. The IR is not verified against existing
+// pins.
+func (c *ConjurePluginConfig) CurrentPins(remoteParams ...conjureplugin.RemoteParam) (map[string][]string, error) {
+	yamlParams, err := c.yamlParams()
+	if err != nil {
+		return nil, err
+	}
+	var expander extensionsExpander
+	pins := make(map[string][]string)
+	for key, currConfig := range c.ProjectConfigs {
+		projectYAMLParams, err := expander.projectYAMLParams(yamlParams, currConfig)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid extensions for %s", key)
+		}
+		sources := currConfig.IRLocator.Sources
+		if len(sources) == 0 {
+			sources = []v1.IRLocatorConfig{currConfig.IRLocator}
+		}
+		digests := make([]string, len(sources))
+		hasDigest := false
+		for i, source := range sources {
+			locatorCfg := IRLocatorConfig(source)
+			provider, err := locatorCfg.toUnpinnedIRProvider(projectYAMLParams, remoteParams)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to convert configuration for %s to provider", key)
+			}
+			if provider.GeneratedFromYAML() && locatorCfg.SHA256 == "" {
+				continue
+			}
+			irBytes, err := provider.IRBytes()
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get IR for %s", key)
+			}
+			digests[i] = fmt.Sprintf("%x", sha256.Sum256(irBytes))
+			hasDigest = true
+		}
+		if hasDigest {
+			pins[key] = digests
+		}
+	}
+	return pins, nil
+}
+
+// UpdatePinsInFile sets the "sha256" value of the locators in the configuration file at the provided path to the
+// digests of the IR that they currently provide. The file is only written if its content changes.
+func UpdatePinsInFile(cfgFile string, remoteParams ...conjureplugin.RemoteParam) error {
+	cfgBytes, err := ioutil.ReadFile(cfgFile)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	cfg, err := ReadConfigFromBytes(cfgBytes)
+	if err != nil {
+		return err
+	}
+	pins, err := cfg.CurrentPins(remoteParams...)
+	if err != nil {
+		return err
+	}
+	updatedBytes, err := UpdatePins(cfgBytes, pins)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(cfgBytes, updatedBytes) {
+		return nil
+	}
+	return errors.WithStack(ioutil.WriteFile(cfgFile, updatedBytes, 0644))
+}
+
+// UpdatePins returns the provided configuration YAML with the "sha256" value of the sources of the "ir-locator" of
+// every project in the provided map set to the digests in the map. The digests of a project correspond to the elements
+// of the "sources" of its locator, or to the locator itself if it does not specify sources. Sources whose digest is the
+// empty string are not modified. Locators specified as strings are converted to objects. Only the lines that contain
+// the updated locators are modified: the indentation, ordering and comments of the rest of the configuration are
+// preserved. Returns the input unmodified if the map is empty.
+func UpdatePins(cfgBytes []byte, pins map[string][]string) ([]byte, error) {
+	if len(pins) == 0 {
+		return cfgBytes, nil
+	}
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(cfgBytes, &root); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal configuration")
+	}
+	if len(root.Content) == 0 {
+		return nil, errors.Errorf("configuration does not define any projects")
+	}
+	_, projectsNode := mappingEntry(root.Content[0], "projects")
+	if projectsNode == nil {
+		return nil, errors.Errorf("configuration does not define any projects")
+	}
+	lines := strings.Split(string(cfgBytes), "\n")
+	edits := make(map[int][]string)
+	for key, digests := range pins {
+		projectKeyNode, projectNode := mappingEntry(projectsNode, key)
+		if projectNode == nil {
+			return nil, errors.Errorf("project %s is not defined in configuration", key)
+		}
+		locatorKeyNode, locatorNode := mappingEntry(projectNode, "ir-locator")
+		if locatorNode == nil {
+			return nil, errors.Errorf("project %s does not define an ir-locator", key)
+		}
+		// the indentation used by the file for nested objects, which is used for locators converted to objects
+		indent := locatorKeyNode.Column - projectKeyNode.Column
+		if indent <= 0 {
+			indent = 2
+		}
+		sourceNodes := []*yamlv3.Node{locatorNode}
+		inSequence := false
+		if locatorNode.Kind == yamlv3.SequenceNode {
+			sourceNodes, inSequence = locatorNode.Content, true
+		} else if _, sourcesNode := mappingEntry(locatorNode, "sources"); sourcesNode != nil && sourcesNode.Kind == yamlv3.SequenceNode {
+			sourceNodes, inSequence = sourcesNode.Content, true
+		}
+		if len(sourceNodes) != len(digests) {
+			return nil, errors.Errorf("ir-locator for project %s has %d sources, but %d digests were provided", key, len(sourceNodes), len(digests))
+		}
+		for i, digest := range digests {
+			if digest == "" {
+				continue
+			}
+			lineIdx, newLines, err := locatorSHA256Edit(lines, sourceNodes[i], inSequence, locatorKeyNode.Column-1+indent, digest)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to update ir-locator for project %s", key)
+			}
+			if _, ok := edits[lineIdx]; ok {
+				return nil, errors.Errorf("failed to update ir-locator for project %s: multiple locators are defined on line %d", key, lineIdx+1)
+			}
+			edits[lineIdx] = newLines
+		}
+	}
+
+	// apply the edits from the last line to the first so that the line indices of pending edits remain valid
+	lineIdxs := make([]int, 0, len(edits))
+	for lineIdx := range edits {
+		lineIdxs = append(lineIdxs, lineIdx)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(lineIdxs)))
+	for _, lineIdx := range lineIdxs {
+		lines = append(lines[:lineIdx], append(edits[lineIdx], lines[lineIdx+1:]...)...)
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// locatorSHA256Edit returns the index of the line of the provided lines that must be replaced to set the "sha256" value
+// of the provided locator node and the lines that replace it. A locator specified as a string is converted to an
+// object: if the locator is an element of a sequence, the object is written inline after the "-" of the element, and
+// otherwise its keys are written on new lines indented by keyIndent spaces.
+func locatorSHA256Edit(lines []string, locatorNode *yamlv3.Node, inSequence bool, keyIndent int, digest string) (int, []string, error) {
+	if locatorNode.Style&yamlv3.FlowStyle != 0 {
+		return 0, nil, errors.Errorf("ir-locator written in flow style cannot be pinned")
+	}
+	switch locatorNode.Kind {
+	case yamlv3.ScalarNode:
+		lineIdx, start, _, err := scalarSpan(lines, locatorNode)
+		if err != nil {
+			return 0, nil, err
+		}
+		line := lines[lineIdx]
+		if inSequence {
+			return lineIdx, []string{
+				line[:start] + "locator: " + line[start:],
+				strings.Repeat(" ", locatorNode.Column-1) + "sha256: " + digest,
+			}, nil
+		}
+		return lineIdx, []string{
+			strings.TrimRight(line[:start], " "),
+			strings.Repeat(" ", keyIndent) + "locator: " + line[start:],
+			strings.Repeat(" ", keyIndent) + "sha256: " + digest,
+		}, nil
+	case yamlv3.MappingNode:
+		if _, valueNode := mappingEntry(locatorNode, "sha256"); valueNode != nil {
+			lineIdx, start, end, err := scalarSpan(lines, valueNode)
+			if err != nil {
+				return 0, nil, err
+			}
+			line := lines[lineIdx]
+			return lineIdx, []string{line[:start] + digest + line[end:]}, nil
+		}
+		keyNode, valueNode := mappingEntry(locatorNode, "locator")
+		if valueNode == nil {
+			return 0, nil, errors.Errorf("ir-locator does not define a locator")
+		}
+		lineIdx, _, _, err := scalarSpan(lines, valueNode)
+		if err != nil {
+			return 0, nil, err
+		}
+		return lineIdx, []string{
+			lines[lineIdx],
+			strings.Repeat(" ", keyNode.Column-1) + "sha256: " + digest,
+		}, nil
+	default:
+		return 0, nil, errors.Errorf("ir-locator must be a string or an object")
+	}
+}
+
+// scalarSpan returns the index of the line of the provided lines that contains the provided scalar node and the byte
+// offsets of the start and end of the scalar within the line. Returns an error if the scalar spans multiple lines.
+func scalarSpan(lines []string, node *yamlv3.Node) (int, int, int, error) {
+	if node.Kind != yamlv3.ScalarNode || node.Style&(yamlv3.LiteralStyle|yamlv3.FoldedStyle) != 0 || node.Line < 1 || node.Line > len(lines) {
+		return 0, 0, 0, errors.Errorf("value on line %d must be a single-line string", node.Line)
+	}
+	lineIdx := node.Line - 1
+	line := lines[lineIdx]
+	// columns are counted in runes
+	start := len(line)
+	for col, offset := 1, 0; offset < len(line); col++ {
+		if col == node.Column {
+			start = offset
+			break
+		}
+		_, size := utf8.DecodeRuneInString(line[offset:])
+		offset += size
+	}
+	rest := line[start:]
+	end := -1
+	switch {
+	case strings.HasPrefix(rest, `"`):
+		for i := 1; i < len(rest); i++ {
+			if rest[i] == '\\' {
+				i++
+			} else if rest[i] == '"' {
+				end = start + i + 1
+				break
+			}
+		}
+	case strings.HasPrefix(rest, "'"):
+		for i := 1; i < len(rest); i++ {
+			if rest[i] == '\'' {
+				if i+1 < len(rest) && rest[i+1] == '\'' {
+					i++
+					continue
+				}
+				end = start + i + 1
+				break
+			}
+		}
+	default:
+		// a plain scalar ends before a comment or at the end of the line
+		if commentIdx := strings.Index(rest, " #"); commentIdx != -1 {
+			rest = rest[:commentIdx]
+		}
+		if rest = strings.TrimRight(rest, " \t\r"); rest != "" && strings.TrimSpace(rest) == node.Value {
+			end = start + len(rest)
+		}
+	}
+	if end == -1 {
+		return 0, 0, 0, errors.Errorf("value on line %d must be a single-line string", node.Line)
+	}
+	return lineIdx, start, end, nil
+}
+
+// mappingEntry returns the key and value nodes for the provided key in the provided mapping node. Returns nil nodes if
+// the node is not a mapping node or if it does not contain the key.
+func mappingEntry(node *yamlv3.Node, key string) (*yamlv3.Node, *yamlv3.Node) {
+	if node.Kind != yamlv3.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config_test
+
+import (
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdatePins(t *testing.T) {
+	const (
+		digestA = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+		digestB = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	)
+	for i, tc := range []struct {
+		in   string
+		pins map[string][]string
+		want string
+	}{
+		{
+			in: `version: 1
+projects:
+  # remote project
+  project-1:
+    output-dir: conjure
+    ir-locator: https://foo.com/ir.json # the locator
+  project-2:
+    output-dir: conjure2
+    ir-locator:
+      type: ir-file
+      locator: ir.json
+      sha256: ` + digestA + `
+  project-3:
+    output-dir: conjure3
+    ir-locator: yaml-dir
+  project-4:
+    output-dir: conjure4
+    ir-locator:
+      - https://foo.com/ir.json
+      - yaml-dir
+`,
+			pins: map[string][]string{
+				"project-1": {digestA},
+				"project-2": {digestB},
+				"project-4": {digestA, ""},
+			},
+			want: `version: 1
+projects:
+  # remote project
+  project-1:
+    output-dir: conjure
+    ir-locator:
+      locator: https://foo.com/ir.json # the locator
+      sha256: ` + digestA + `
+  project-2:
+    output-dir: conjure2
+    ir-locator:
+      type: ir-file
+      locator: ir.json
+      sha256: ` + digestB + `
+  project-3:
+    output-dir: conjure3
+    ir-locator: yaml-dir
+  project-4:
+    output-dir: conjure4
+    ir-locator:
+      - locator: https://foo.com/ir.json
+        sha256: ` + digestA + `
+      - yaml-dir
+`,
+		},
+		{
+			in: `# config with 4-space indentation
+projects:
+    project-1:
+        ir-locator:   "https://foo.com/ir.json"   # the locator
+        output-dir: conjure # generated
+    project-2:
+        ir-locator:
+            sources:
+              - type: remote
+                locator: https://foo.com/ir.json
+              # second source
+              - locator: https://bar.com/ir.json
+                sha256: '` + digestA + `' # pinned
+version: 1
+`,
+			pins: map[string][]string{
+				"project-1": {digestA},
+				"project-2": {digestB, digestB},
+			},
+			want: `# config with 4-space indentation
+projects:
+    project-1:
+        ir-locator:
+            locator: "https://foo.com/ir.json"   # the locator
+            sha256: ` + digestA + `
+        output-dir: conjure # generated
+    project-2:
+        ir-locator:
+            sources:
+              - type: remote
+                locator: https://foo.com/ir.json
+                sha256: ` + digestB + `
+              # second source
+              - locator: https://bar.com/ir.json
+                sha256: ` + digestB + ` # pinned
+version: 1
+`,
+		},
+	} {
+		got, err := config.UpdatePins([]byte(tc.in), tc.pins)
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, string(got), "Case %d\nGot:\n%s", i, got)
+
+		cfg, err := config.ReadConfigFromBytes(got)
+		require.NoError(t, err, "Case %d", i)
+		for key, digests := range tc.pins {
+			locatorCfg := cfg.ProjectConfigs[key].IRLocator
+			if len(locatorCfg.Sources) == 0 {
+				assert.Equal(t, digests, []string{locatorCfg.SHA256}, "Case %d", i)
+				continue
+			}
+			for j, digest := range digests {
+				assert.Equal(t, digest, locatorCfg.Sources[j].SHA256, "Case %d", i)
+			}
+		}
+	}
+
+	_, err := config.UpdatePins([]byte("projects:\n  project-1:\n    output-dir: conjure\n"), map[string][]string{"project-2": {digestA}})
+	assert.EqualError(t, err, "project project-2 is not defined in configuration")
+
+	_, err = config.UpdatePins([]byte("projects:\n  project-1:\n    ir-locator: {locator: ir.json}\n"), map[string][]string{"project-1": {digestA}})
+	assert.EqualError(t, err, "failed to update ir-locator for project project-1: ir-locator written in flow style cannot be pinned")
+}
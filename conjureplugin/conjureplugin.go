@@ -17,17 +17,203 @@ package conjureplugin
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
 	"path"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/palantir/conjure-go/v6/conjure"
 	conjurego "github.com/palantir/conjure-go/v6/conjure"
 	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+	"github.com/palantir/godel/v2/pkg/dirchecksum"
+	"github.com/pkg/errors"
 )
 
 const indentLen = 2
 
-func Run(params ConjureProjectParams, verify bool, projectDir string, stdout io.Writer) error {
+type runArgs struct {
+	lockfilePath string
+	parallelism  int
+	diffContext  int
+	diffMaxLines int
+	verifyFormat VerifyFormat
+	// includeProjects and excludeProjects are the patterns of the keys of the projects that are run.
+	includeProjects []string
+	excludeProjects []string
+	// onlyProjects are the keys of the projects to which the projects that are run are further restricted, if non-nil.
+	onlyProjects  []string
+	stateFilePath string
+	force         bool
+	dryRun        bool
+}
+
+type RunParam interface {
+	apply(*runArgs)
+}
+
+type runParamFn func(*runArgs)
+
+func (fn runParamFn) apply(r *runArgs) {
+	fn(r)
+}
+
+// LockfileParam returns a parameter that configures Run to write the lockfile for the parameters to the provided path.
+// When Run verifies, it instead fails if the lockfile at the path does not match the IR that is currently resolved.
+func LockfileParam(lockfilePath string) RunParam {
+	return runParamFn(func(r *runArgs) {
+		r.lockfilePath = lockfilePath
+	})
+}
+
+// ParallelismParam returns a parameter that configures the maximum number of projects that Run processes concurrently.
+// If the provided value is 0 or less, the number of CPUs is used. By default, projects are processed one at a time.
+func ParallelismParam(parallelism int) RunParam {
+	return runParamFn(func(r *runArgs) {
+		r.parallelism = parallelism
+		if parallelism <= 0 {
+			r.parallelism = runtime.NumCPU()
+		}
+	})
+}
+
+// DiffContextParam returns a parameter that configures the number of lines of context in the diffs that Run prints when
+// it verifies. Defaults to 3.
+func DiffContextParam(context int) RunParam {
+	return runParamFn(func(r *runArgs) {
+		r.diffContext = context
+	})
+}
+
+// DiffMaxLinesParam returns a parameter that configures the maximum number of lines of the diff of a single file that
+// Run prints when it verifies. Longer diffs are truncated. If the provided value is 0 or less, diffs are not truncated.
+// Defaults to 200.
+func DiffMaxLinesParam(maxLines int) RunParam {
+	return runParamFn(func(r *runArgs) {
+		r.diffMaxLines = maxLines
+	})
+}
+
+// VerifyFormatParam returns a parameter that configures the format of the report that Run prints when it verifies.
+// Defaults to VerifyFormatText.
+func VerifyFormatParam(format VerifyFormat) RunParam {
+	return runParamFn(func(r *runArgs) {
+		r.verifyFormat = format
+	})
+}
+
+// ProjectsParam returns a parameter that configures Run to only run the projects whose keys match at least one of the
+// provided include patterns (or all projects if there are none) and none of the provided exclude patterns. The
+// patterns are matched as described by ConjureProjectParams.FilterProjects. The lockfile entries of the projects that
+// are not run are preserved, and files in their output directories are never considered stale.
+func ProjectsParam(include, exclude []string) RunParam {
+	return runParamFn(func(r *runArgs) {
+		r.includeProjects = include
+		r.excludeProjects = exclude
+	})
+}
+
+// onlyProjectsParam returns a parameter that configures Run to only run the projects with the provided keys among the
+// projects that are selected by the ProjectsParam, if any. Unlike ProjectsParam, the keys are not patterns.
+func onlyProjectsParam(keys []string) RunParam {
+	return runParamFn(func(r *runArgs) {
+		r.onlyProjects = keys
+	})
+}
+
+// StateFileParam returns a parameter that configures Run to record the state of the output of every project that it
+// generates in the file at the provided path. Run skips the generation of a project if the IR, the output
+// configuration and the version of conjure-go match the recorded state and the files that were generated still have
+// the recorded checksums. Verification is never skipped.
+func StateFileParam(stateFilePath string) RunParam {
+	return runParamFn(func(r *runArgs) {
+		r.stateFilePath = stateFilePath
+	})
+}
+
+// ForceParam returns a parameter that configures Run to generate every project even if the state file records that its
+// output is up to date.
+func ForceParam(force bool) RunParam {
+	return runParamFn(func(r *runArgs) {
+		r.force = force
+	})
+}
+
+// DryRunParam returns a parameter that configures Run to print the files that generation would create, modify, leave
+// unchanged or delete for every project without writing or removing any files or directories. The lockfile and the
+// state file are not written either. Run fails if it is configured to both verify and perform a dry run.
+func DryRunParam(dryRun bool) RunParam {
+	return runParamFn(func(r *runArgs) {
+		r.dryRun = dryRun
+	})
+}
+
+// projectResult is the result of processing a single project in Run.
+type projectResult struct {
+	lockedProject LockedProject
+	verifyDiff    dirchecksum.ChecksumsDiff
+	// files are the files that are generated for the project.
+	files []*conjure.OutputFile
+	// outputDir is the absolute path of the output directory of the project.
+	outputDir string
+	// generatedPaths are the absolute paths of the files that are generated for the project.
+	generatedPaths []string
+	// state is the state of the output of the project after it was generated. Not set when verifying.
+	state projectGenerationState
+	err   error
+}
+
+func Run(params ConjureProjectParams, verify bool, projectDir string, stdout io.Writer, runParams ...RunParam) error {
+	runArgCollector := newRunArgs(runParams...)
+	// a dry run computes the output of every project like verification does, but reports it instead of failing
+	dryRun := runArgCollector.dryRun
+	if dryRun && verify {
+		return errors.Errorf("dry run cannot be used when verifying")
+	}
+
+	lockfile := Lockfile{
+		Version:  1,
+		Projects: make(map[string]LockedProject),
+	}
+
+	// verify failures are reported using the index of the project among all of the projects
+	allParams := params
+	projectIndices := make(map[string]int)
+	for i, k := range allParams.SortedKeys {
+		projectIndices[k] = i
+	}
+	var unselectedOutputDirs []string
+	if len(runArgCollector.includeProjects) > 0 || len(runArgCollector.excludeProjects) > 0 || runArgCollector.onlyProjects != nil {
+		var err error
+		if params, err = allParams.FilterProjects(runArgCollector.includeProjects, runArgCollector.excludeProjects); err != nil {
+			return err
+		}
+		if runArgCollector.onlyProjects != nil {
+			params = params.withKeys(runArgCollector.onlyProjects)
+		}
+		var existingLockfile Lockfile
+		if runArgCollector.lockfilePath != "" {
+			if existingLockfile, _, err = ReadLockfile(runArgCollector.lockfilePath); err != nil {
+				return err
+			}
+		}
+		for _, k := range allParams.SortedKeys {
+			if _, ok := params.Params[k]; ok {
+				continue
+			}
+			if lockedProject, ok := existingLockfile.Projects[k]; ok {
+				lockfile.Projects[k] = lockedProject
+			}
+			outputDir, err := filepath.Abs(path.Join(projectDir, allParams.Params[k].OutputDir))
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			unselectedOutputDirs = append(unselectedOutputDirs, outputDir)
+		}
+	}
+
 	var verifyFailedIndex []int
 	verifyFailedErrors := make(map[int]string)
 	verifyFailedFn := func(name int, errStr string) {
@@ -35,35 +221,161 @@ func Run(params ConjureProjectParams, verify bool, projectDir string, stdout io.
 		verifyFailedErrors[name] = errStr
 	}
 
-	k := 0
-	for _, currParam := range params.OrderedParams() {
-		outputDir := currParam.OutputDir
-		conjureDef, err := conjureDefinitionFromParam(currParam)
+	var previousState generationState
+	if runArgCollector.stateFilePath != "" && !verify && !dryRun {
+		previousState = readGenerationState(runArgCollector.stateFilePath)
+	}
+
+	// compile the YAML of all of the projects that can be compiled together up front
+	batchIR := compileYAMLBatch(params)
+
+	orderedParams := params.OrderedParams()
+	results := make([]projectResult, len(orderedParams))
+	// projects whose output directories overlap are generated by the same worker so that they never write to the same
+	// directory concurrently
+	runParallel(outputDirGroups(orderedParams, projectDir), runArgCollector.parallelism, func(i int) {
+		var previous *projectGenerationState
+		if projectState, ok := previousState.Projects[params.SortedKeys[i]]; ok && !runArgCollector.force {
+			previous = &projectState
+		}
+		results[i] = runProject(orderedParams[i], params.SortedKeys[i], batchIR[params.SortedKeys[i]], verify, dryRun, projectDir, runArgCollector.lockfilePath != "", previous)
+	})
+
+	// results are processed in the order of the projects so that the output does not depend on the order in which
+	// the projects finished
+	var projectErrs []error
+	generatedPaths := make(map[string]struct{})
+	for _, result := range results {
+		if result.err != nil {
+			projectErrs = append(projectErrs, result.err)
+			continue
+		}
+		for _, generatedPath := range result.generatedPaths {
+			generatedPaths[generatedPath] = struct{}{}
+		}
+	}
+	switch len(projectErrs) {
+	case 0:
+	case 1:
+		return projectErrs[0]
+	default:
+		var errStrs []string
+		for _, err := range projectErrs {
+			errStrs = append(errStrs, strings.ReplaceAll(err.Error(), "\n", "\n"+strings.Repeat(" ", indentLen)))
+		}
+		return errors.Errorf("failed to run conjure for %d projects:\n%s%s", len(projectErrs), strings.Repeat(" ", indentLen), strings.Join(errStrs, "\n"+strings.Repeat(" ", indentLen)))
+	}
+
+	absProjectDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// files generated by a previous run that are not generated by any project are stale. Output directories may be
+	// shared or nested, so a stale file is only attributed to the first project whose output directory contains it.
+	staleFiles := make(map[string]struct{})
+	projectDiffs := make(map[string]dirchecksum.ChecksumsDiff)
+	for i, result := range results {
+		if runArgCollector.lockfilePath != "" {
+			lockfile.Projects[params.SortedKeys[i]] = result.lockedProject
+		}
+		projectStaleFiles, err := staleGeneratedFiles(result.outputDir, generatedPaths)
 		if err != nil {
 			return err
 		}
-
-		outputConf := conjure.OutputConfiguration{
-			OutputDir:            path.Join(projectDir, outputDir),
-			GenerateServer:       currParam.Server,
-			GenerateFuncsVisitor: currParam.AcceptFuncs,
+		for _, staleFile := range projectStaleFiles {
+			if _, ok := staleFiles[staleFile]; ok || isInAnyDir(staleFile, unselectedOutputDirs) {
+				continue
+			}
+			staleFiles[staleFile] = struct{}{}
+			if !verify && !dryRun {
+				if err := removeStaleGeneratedFile(result.outputDir, staleFile); err != nil {
+					return err
+				}
+				continue
+			}
+			relPath, err := filepath.Rel(absProjectDir, staleFile)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			result.verifyDiff.Diffs[relPath] = staleFileDiff
 		}
 		if verify {
-			diff, err := diffOnDisk(conjureDef, projectDir, outputConf)
-			if err != nil {
+			projectDiffs[params.SortedKeys[i]] = result.verifyDiff
+		}
+		if dryRun {
+			if err := printDryRun(stdout, params.SortedKeys[i], result, absProjectDir); err != nil {
 				return err
 			}
-			if len(diff.Diffs) > 0 {
-				verifyFailedFn(k, diff.String())
+			continue
+		}
+		if len(result.verifyDiff.Diffs) > 0 && runArgCollector.verifyFormat == VerifyFormatText {
+			diffs, err := renderDiffs(result.verifyDiff, result.files, runArgCollector.diffContext, runArgCollector.diffMaxLines)
+			if err != nil {
+				return errors.Wrapf(err, "failed to compute diff for %s", params.SortedKeys[i])
 			}
-		} else {
-			if err := conjure.Generate(conjureDef, outputConf); err != nil {
+			verifyFailedFn(projectIndices[params.SortedKeys[i]], diffs)
+		}
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	var staleLockEntries []string
+	if runArgCollector.lockfilePath != "" {
+		if verify {
+			existingLockfile, exists, err := ReadLockfile(runArgCollector.lockfilePath)
+			if err != nil {
 				return err
 			}
+			if !exists {
+				staleLockEntries = []string{fmt.Sprintf("%s does not exist", LockfileName)}
+			} else {
+				staleLockEntries = existingLockfile.StaleEntries(lockfile)
+			}
+		} else if err := WriteLockfile(runArgCollector.lockfilePath, lockfile); err != nil {
+			return errors.Wrapf(err, "failed to write lockfile")
+		}
+	}
+	if runArgCollector.stateFilePath != "" && !verify {
+		// the state of the projects that were not run is preserved
+		state := generationState{
+			Version:  1,
+			Projects: make(map[string]projectGenerationState),
+		}
+		for _, k := range allParams.SortedKeys {
+			if projectState, ok := previousState.Projects[k]; ok {
+				state.Projects[k] = projectState
+			}
+		}
+		for i, result := range results {
+			state.Projects[params.SortedKeys[i]] = result.state
+		}
+		if err := writeGenerationState(runArgCollector.stateFilePath, state); err != nil {
+			return err
 		}
-		k++
 	}
 
+	if verify && runArgCollector.verifyFormat != VerifyFormatText {
+		lockfileRelPath := ""
+		if runArgCollector.lockfilePath != "" {
+			absLockfilePath, err := filepath.Abs(runArgCollector.lockfilePath)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if lockfileRelPath, err = filepath.Rel(absProjectDir, absLockfilePath); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		report := newVerifyReport(projectDiffs, staleLockEntries)
+		if err := writeVerifyReport(stdout, runArgCollector.verifyFormat, report, lockfileRelPath); err != nil {
+			return err
+		}
+		if report.Failed() {
+			return fmt.Errorf("conjure verify failed")
+		}
+		return nil
+	}
 	if verify && len(verifyFailedIndex) > 0 {
 		_, _ = fmt.Fprintf(stdout, "Conjure output differs from what currently exists: %v\n", verifyFailedIndex)
 		for _, currKey := range verifyFailedIndex {
@@ -72,19 +384,243 @@ func Run(params ConjureProjectParams, verify bool, projectDir string, stdout io.
 				_, _ = fmt.Fprintf(stdout, "%s%s\n", strings.Repeat(" ", indentLen*2), currErrLine)
 			}
 		}
+	}
+	if verify && len(staleLockEntries) > 0 {
+		printStaleLockEntries(stdout, staleLockEntries)
+	}
+	if verify && (len(verifyFailedIndex) > 0 || len(staleLockEntries) > 0) {
 		return fmt.Errorf("conjure verify failed")
 	}
 	return nil
 }
 
-func conjureDefinitionFromParam(param ConjureProjectParam) (spec.ConjureDefinition, error) {
-	bytes, err := param.IRProvider.IRBytes()
+// isInAnyDir returns true if the provided path is in any of the provided directories or their subdirectories. All of
+// the paths must be absolute.
+func isInAnyDir(filePath string, dirs []string) bool {
+	for _, dir := range dirs {
+		if strings.HasPrefix(filePath, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func newRunArgs(runParams ...RunParam) runArgs {
+	runArgCollector := runArgs{
+		parallelism:  1,
+		diffContext:  3,
+		diffMaxLines: 200,
+		verifyFormat: VerifyFormatText,
+	}
+	for _, param := range runParams {
+		if param == nil {
+			continue
+		}
+		param.apply(&runArgCollector)
+	}
+	return runArgCollector
+}
+
+// runProject generates the provided project or, if verify or dryRun is true, returns the difference between the output
+// for the project and the files that are currently on disk. A dry run never creates the output directory of the
+// project. The lock of the project is computed if lock is true. The result records the paths of the files that are
+// generated for the project in either case. If the provided previous state is non-nil and the output of the project is
+// up to date according to it, the project is not generated again.
+func runProject(param ConjureProjectParam, key string, batchIR []byte, verify, dryRun bool, projectDir string, lock bool, previous *projectGenerationState) projectResult {
+	conjureDef, irBytes, err := conjureDefinitionFromParam(param, batchIR)
+	if err != nil {
+		return projectResult{err: errors.Wrapf(err, "failed to get IR for %s", key)}
+	}
+	if conjureDef, err = param.DefinitionFilter.Apply(conjureDef); err != nil {
+		return projectResult{err: errors.Wrapf(err, "failed to filter definitions of %s", key)}
+	}
+	var result projectResult
+	if lock {
+		if result.lockedProject, err = newLockedProject(param.IRProvider, irBytes); err != nil {
+			return projectResult{err: errors.Wrapf(err, "failed to compute lock for %s", key)}
+		}
+	}
+
+	outputConf := conjure.OutputConfiguration{
+		OutputDir:            path.Join(projectDir, param.OutputDir),
+		GenerateServer:       param.Server,
+		GenerateFuncsVisitor: param.AcceptFuncs,
+	}
+	if result.outputDir, err = filepath.Abs(outputConf.OutputDir); err != nil {
+		return projectResult{err: errors.WithStack(err)}
+	}
+	absProjectDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return projectResult{err: errors.WithStack(err)}
+	}
+	result.state = projectGenerationState{
+		IRSHA256:         irDigest(irBytes),
+		OutputDir:        param.OutputDir,
+		GenerateServer:   param.Server,
+		AcceptFuncs:      param.AcceptFuncs,
+		DefinitionFilter: param.DefinitionFilter.String(),
+		ConjureGoVersion: conjureGoVersion(),
+		Files:            make(map[string]string),
+	}
+	if !verify && !dryRun && previous != nil && previous.sameInputs(result.state) && previous.filesUpToDate(absProjectDir) {
+		for relPath := range previous.Files {
+			result.generatedPaths = append(result.generatedPaths, filepath.Join(absProjectDir, relPath))
+		}
+		result.state = *previous
+		return result
+	}
+	if dryRun {
+		if _, err := os.Stat(result.outputDir); os.IsNotExist(err) {
+			// conjure-go creates the output directory, so the output is computed without conjure-go writing to it
+			if result.generatedPaths, err = dryRunGeneratedPaths(conjureDef, outputConf); err != nil {
+				return projectResult{err: errors.Wrapf(err, "failed to compute the output of %s", key)}
+			}
+			result.verifyDiff = dirchecksum.ChecksumsDiff{
+				RootDir: projectDir,
+				Diffs:   make(map[string]string),
+			}
+			for _, generatedPath := range result.generatedPaths {
+				relPath, err := filepath.Rel(absProjectDir, generatedPath)
+				if err != nil {
+					return projectResult{err: errors.WithStack(err)}
+				}
+				result.verifyDiff.Diffs[relPath] = "extra"
+			}
+			return result
+		}
+	}
+	files, err := conjure.GenerateOutputFiles(conjureDef, outputConf)
 	if err != nil {
-		return spec.ConjureDefinition{}, err
+		if verify || dryRun {
+			err = errors.Wrap(err, "conjure failed")
+		}
+		return projectResult{err: err}
+	}
+	for _, file := range files {
+		generatedPath, err := filepath.Abs(file.AbsPath())
+		if err != nil {
+			return projectResult{err: errors.WithStack(err)}
+		}
+		result.generatedPaths = append(result.generatedPaths, generatedPath)
+	}
+	if verify || dryRun {
+		if result.verifyDiff, err = diffOnDisk(files, projectDir); err != nil {
+			return projectResult{err: err}
+		}
+		result.files = files
+		return result
+	}
+	for i, file := range files {
+		if err := file.Write(); err != nil {
+			return projectResult{err: err}
+		}
+		content, err := ioutil.ReadFile(result.generatedPaths[i])
+		if err != nil {
+			return projectResult{err: errors.WithStack(err)}
+		}
+		relPath, err := filepath.Rel(absProjectDir, result.generatedPaths[i])
+		if err != nil {
+			return projectResult{err: errors.WithStack(err)}
+		}
+		result.state.Files[relPath] = sha256Digest(content)
+	}
+	return result
+}
+
+// runParallel invokes the provided function for every index of the provided groups using at most the provided number
+// of concurrent invocations and returns once all of the invocations have returned. The indices of a group are invoked
+// one at a time in order.
+func runParallel(groups [][]int, parallelism int, fn func(i int)) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	groupsChan := make(chan []int)
+	wg := &sync.WaitGroup{}
+	for worker := 0; worker < parallelism && worker < len(groups); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for group := range groupsChan {
+				for _, i := range group {
+					fn(i)
+				}
+			}
+		}()
+	}
+	for _, group := range groups {
+		groupsChan <- group
+	}
+	close(groupsChan)
+	wg.Wait()
+}
+
+// outputDirGroups returns the indices of the provided projects grouped so that projects whose output directories are
+// the same or nested within one another are in the same group. The groups are ordered by their first index and the
+// indices of every group are in increasing order.
+func outputDirGroups(orderedParams []ConjureProjectParam, projectDir string) [][]int {
+	// groupOf[i] is the index of the first project of the group of project i
+	groupOf := make([]int, len(orderedParams))
+	for i := range orderedParams {
+		groupOf[i] = i
+	}
+	for i := range orderedParams {
+		for j := 0; j < i; j++ {
+			if !outputDirsOverlap(filepath.Join(projectDir, orderedParams[i].OutputDir), filepath.Join(projectDir, orderedParams[j].OutputDir)) {
+				continue
+			}
+			// merge the groups of i and j into the group with the lower index
+			from, to := groupOf[i], groupOf[j]
+			if from < to {
+				from, to = to, from
+			}
+			for k := range groupOf {
+				if groupOf[k] == from {
+					groupOf[k] = to
+				}
+			}
+		}
+	}
+	var groups [][]int
+	groupIndices := make(map[int]int)
+	for i, group := range groupOf {
+		if _, ok := groupIndices[group]; !ok {
+			groupIndices[group] = len(groups)
+			groups = append(groups, nil)
+		}
+		groups[groupIndices[group]] = append(groups[groupIndices[group]], i)
+	}
+	return groups
+}
+
+// outputDirsOverlap returns true if the provided directories are the same or one is within the other.
+func outputDirsOverlap(dir, otherDir string) bool {
+	_, ok := relPathWithin(dir, otherDir)
+	if !ok {
+		_, ok = relPathWithin(otherDir, dir)
+	}
+	return ok
+}
+
+// printStaleLockEntries prints the provided descriptions of stale lockfile entries.
+func printStaleLockEntries(stdout io.Writer, staleLockEntries []string) {
+	_, _ = fmt.Fprintf(stdout, "%s is out of date:\n", LockfileName)
+	for _, currEntry := range staleLockEntries {
+		_, _ = fmt.Fprintf(stdout, "%s%s\n", strings.Repeat(" ", indentLen), currEntry)
+	}
+}
+
+// conjureDefinitionFromParam returns the definition and IR of the provided project. If the provided IR is non-nil, it
+// is used as the IR of the project instead of the IR of its provider.
+func conjureDefinitionFromParam(param ConjureProjectParam, bytes []byte) (spec.ConjureDefinition, []byte, error) {
+	if bytes == nil {
+		var err error
+		if bytes, err = param.IRProvider.IRBytes(); err != nil {
+			return spec.ConjureDefinition{}, nil, err
+		}
 	}
 	conjureDefinition, err := conjurego.FromIRBytes(bytes)
 	if err != nil {
-		return spec.ConjureDefinition{}, err
+		return spec.ConjureDefinition{}, nil, err
 	}
-	return conjureDefinition, nil
+	return conjureDefinition, bytes, nil
 }
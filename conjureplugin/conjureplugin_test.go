@@ -0,0 +1,583 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunParallel(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, "go.mod"), []byte("module github.com/palantir/test\n"), 0644))
+
+	params := conjureplugin.ConjureProjectParams{
+		Params: make(map[string]conjureplugin.ConjureProjectParam),
+	}
+	for i := 0; i < 6; i++ {
+		key := fmt.Sprintf("project-%d", i)
+		yamlPath := filepath.Join(projectDir, key+".yml")
+		require.NoError(t, ioutil.WriteFile(yamlPath, []byte(fmt.Sprintf(`
+types:
+  definitions:
+    default-package: com.palantir.project%d
+    objects:
+      Object%d:
+        fields:
+          value: string
+`, i, i)), 0644))
+		params.SortedKeys = append(params.SortedKeys, key)
+		params.Params[key] = conjureplugin.ConjureProjectParam{
+			OutputDir:  key,
+			IRProvider: conjureplugin.NewLocalYAMLIRProvider(yamlPath, conjureplugin.YAMLCompilerParam(conjureplugin.YAMLCompilerNative)),
+		}
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, conjureplugin.ParallelismParam(4)))
+
+	// modify the output of some of the projects: the verify report lists them in the order of the projects
+	for _, i := range []int{4, 1} {
+		structsFile := filepath.Join(projectDir, fmt.Sprintf("project-%d", i), "com", "palantir", fmt.Sprintf("project%d", i), "structs.conjure.go")
+		require.NoError(t, ioutil.WriteFile(structsFile, []byte("package modified\n"), 0644))
+	}
+	outputBuf := &bytes.Buffer{}
+	err := conjureplugin.Run(params, true, projectDir, outputBuf, conjureplugin.ParallelismParam(4))
+	assert.EqualError(t, err, "conjure verify failed")
+	assert.Contains(t, outputBuf.String(), "Conjure output differs from what currently exists: [1 4]\n")
+	assert.Regexp(t, "(?s)^[^\n]*\n  1:\n.*project1/structs.conjure.go.*\n  4:\n.*project4/structs.conjure.go", outputBuf.String())
+
+	// errors of all of the projects are reported in the order of the projects
+	for _, i := range []int{5, 2} {
+		require.NoError(t, os.Remove(filepath.Join(projectDir, fmt.Sprintf("project-%d.yml", i))))
+	}
+	err = conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, conjureplugin.ParallelismParam(4))
+	require.Error(t, err)
+	assert.Regexp(t, "^failed to run conjure for 2 projects:\n  failed to get IR for project-2: .*\n  failed to get IR for project-5: ", err.Error())
+}
+
+func TestRunParallelOverlappingOutputDirs(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, "go.mod"), []byte("module github.com/palantir/test\n"), 0644))
+
+	// projects with the same or nested output directories must never be processed concurrently
+	sharedGroup := &concurrencyRecorder{}
+	params := conjureplugin.ConjureProjectParams{
+		Params: make(map[string]conjureplugin.ConjureProjectParam),
+	}
+	for i, outputDir := range []string{"shared", "shared/nested", "other", "shared", "other-2"} {
+		key := fmt.Sprintf("project-%d", i)
+		recorder := &concurrencyRecorder{}
+		if strings.HasPrefix(outputDir, "shared") {
+			recorder = sharedGroup
+		}
+		params.SortedKeys = append(params.SortedKeys, key)
+		params.Params[key] = conjureplugin.ConjureProjectParam{
+			OutputDir:  outputDir,
+			IRProvider: &recordingIRProvider{recorder: recorder},
+		}
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, conjureplugin.ParallelismParam(5)))
+	assert.Equal(t, 3, sharedGroup.calls)
+	assert.Equal(t, 1, sharedGroup.maxActive)
+}
+
+// concurrencyRecorder records the maximum number of concurrent calls of the providers that share it.
+type concurrencyRecorder struct {
+	mu        sync.Mutex
+	active    int
+	maxActive int
+	calls     int
+}
+
+// recordingIRProvider provides an empty IR after a short delay and records its calls in its recorder.
+type recordingIRProvider struct {
+	recorder *concurrencyRecorder
+}
+
+func (p *recordingIRProvider) IRBytes() ([]byte, error) {
+	p.recorder.mu.Lock()
+	p.recorder.active++
+	p.recorder.calls++
+	if p.recorder.active > p.recorder.maxActive {
+		p.recorder.maxActive = p.recorder.active
+	}
+	p.recorder.mu.Unlock()
+
+	time.Sleep(50 * time.Millisecond)
+
+	p.recorder.mu.Lock()
+	p.recorder.active--
+	p.recorder.mu.Unlock()
+	return []byte(testIRJSON), nil
+}
+
+func (p *recordingIRProvider) GeneratedFromYAML() bool {
+	return false
+}
+
+func TestRunRemovesStaleGeneratedFiles(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, "go.mod"), []byte("module github.com/palantir/test\n"), 0644))
+	yamlPath := filepath.Join(projectDir, "api.yml")
+	writeYAML := func(packages ...string) {
+		content := "types:\n  definitions:\n    objects:\n"
+		for _, pkg := range packages {
+			content += fmt.Sprintf("      %s:\n        package: com.palantir.%s\n        fields:\n          value: string\n", strings.ToUpper(pkg[:1])+pkg[1:], pkg)
+		}
+		require.NoError(t, ioutil.WriteFile(yamlPath, []byte(content), 0644))
+	}
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project": {
+				OutputDir:  "conjure",
+				IRProvider: conjureplugin.NewLocalYAMLIRProvider(yamlPath, conjureplugin.YAMLCompilerParam(conjureplugin.YAMLCompilerNative)),
+			},
+		},
+	}
+
+	writeYAML("foo", "bar")
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	barFile := filepath.Join(projectDir, "conjure", "com", "palantir", "bar", "structs.conjure.go")
+	require.FileExists(t, barFile)
+	// files that were not generated by Conjure are never removed
+	handwrittenFile := filepath.Join(projectDir, "conjure", "com", "palantir", "foo", "handwritten.go")
+	require.NoError(t, ioutil.WriteFile(handwrittenFile, []byte("package foo\n"), 0644))
+
+	writeYAML("foo")
+	outputBuf := &bytes.Buffer{}
+	err := conjureplugin.Run(params, true, projectDir, outputBuf)
+	assert.EqualError(t, err, "conjure verify failed")
+	assert.Contains(t, outputBuf.String(), fmt.Sprintf(`Conjure output differs from what currently exists: [0]
+  0:
+    --- %s	on disk
+    +++ %s	not generated
+    @@ -1,29 +0,0 @@
+    -// This file was generated by Conjure and should not be manually edited.
+`, barFile, barFile))
+
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+	assert.NoDirExists(t, filepath.Dir(barFile))
+	assert.FileExists(t, handwrittenFile)
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}))
+}
+
+func TestRunVerifyPrintsDiffs(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, "go.mod"), []byte("module github.com/palantir/test\n"), 0644))
+	yamlPath := filepath.Join(projectDir, "api.yml")
+	require.NoError(t, ioutil.WriteFile(yamlPath, []byte(`
+types:
+  definitions:
+    default-package: com.palantir.foo
+    objects:
+      Foo:
+        fields:
+          value: string
+`), 0644))
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project": {
+				OutputDir:  "conjure",
+				IRProvider: conjureplugin.NewLocalYAMLIRProvider(yamlPath, conjureplugin.YAMLCompilerParam(conjureplugin.YAMLCompilerNative)),
+			},
+		},
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+
+	structsFile := filepath.Join(projectDir, "conjure", "com", "palantir", "foo", "structs.conjure.go")
+	content, err := ioutil.ReadFile(structsFile)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(structsFile, bytes.Replace(content, []byte("Value string"), []byte("Value int"), 1), 0644))
+
+	outputBuf := &bytes.Buffer{}
+	err = conjureplugin.Run(params, true, projectDir, outputBuf, conjureplugin.DiffContextParam(1))
+	assert.EqualError(t, err, "conjure verify failed")
+	assert.Equal(t, fmt.Sprintf(`Conjure output differs from what currently exists: [0]
+  0:
+    --- %s	on disk
+    +++ %s	generated
+    @@ -10,3 +10,3 @@
+     type Foo struct {
+    -	Value int `+"`json:\"value\"`"+`
+    +	Value string `+"`json:\"value\"`"+`
+     }
+`, structsFile, structsFile), outputBuf.String())
+
+	outputBuf = &bytes.Buffer{}
+	err = conjureplugin.Run(params, true, projectDir, outputBuf, conjureplugin.DiffContextParam(1), conjureplugin.DiffMaxLinesParam(4))
+	assert.EqualError(t, err, "conjure verify failed")
+	assert.Equal(t, fmt.Sprintf(`Conjure output differs from what currently exists: [0]
+  0:
+    --- %s	on disk
+    +++ %s	generated
+    @@ -10,3 +10,3 @@
+     type Foo struct {
+    ... (3 more lines)
+`, structsFile, structsFile), outputBuf.String())
+}
+
+func TestRunVerifyReport(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, "go.mod"), []byte("module github.com/palantir/test\n"), 0644))
+	yamlPath := filepath.Join(projectDir, "api.yml")
+	require.NoError(t, ioutil.WriteFile(yamlPath, []byte(`
+types:
+  definitions:
+    objects:
+      Foo:
+        package: com.palantir.foo
+        fields:
+          value: string
+      Bar:
+        package: com.palantir.bar
+        fields:
+          value: string
+`), 0644))
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1", "project-2"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure",
+				IRProvider: conjureplugin.NewLocalYAMLIRProvider(yamlPath, conjureplugin.YAMLCompilerParam(conjureplugin.YAMLCompilerNative)),
+			},
+			"project-2": {
+				OutputDir:  "conjure-2",
+				IRProvider: conjureplugin.NewLocalYAMLIRProvider(yamlPath, conjureplugin.YAMLCompilerParam(conjureplugin.YAMLCompilerNative)),
+			},
+		},
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+
+	outputBuf := &bytes.Buffer{}
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, outputBuf, conjureplugin.VerifyFormatParam(conjureplugin.VerifyFormatJSON)))
+	var report conjureplugin.VerifyReport
+	require.NoError(t, json.Unmarshal(outputBuf.Bytes(), &report))
+	assert.False(t, report.Failed())
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, "conjure", "com", "palantir", "foo", "structs.conjure.go"), []byte("package foo\n"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(projectDir, "conjure", "com", "palantir", "bar", "structs.conjure.go")))
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, "conjure", "com", "palantir", "baz"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, "conjure", "com", "palantir", "baz", "structs.conjure.go"), []byte("// This file was generated by Conjure and should not be manually edited.\n\npackage baz\n"), 0644))
+
+	outputBuf = &bytes.Buffer{}
+	err := conjureplugin.Run(params, true, projectDir, outputBuf, conjureplugin.VerifyFormatParam(conjureplugin.VerifyFormatJSON))
+	assert.EqualError(t, err, "conjure verify failed")
+	assert.Equal(t, `{
+  "projects": {
+    "project-1": {
+      "missing": [
+        "conjure/com/palantir/bar/structs.conjure.go"
+      ],
+      "changed": [
+        "conjure/com/palantir/foo/structs.conjure.go"
+      ],
+      "extra": [
+        "conjure/com/palantir/baz/structs.conjure.go"
+      ]
+    },
+    "project-2": {
+      "missing": [],
+      "changed": [],
+      "extra": []
+    }
+  },
+  "staleLockEntries": []
+}
+`, outputBuf.String())
+
+	outputBuf = &bytes.Buffer{}
+	err = conjureplugin.Run(params, true, projectDir, outputBuf, conjureplugin.VerifyFormatParam(conjureplugin.VerifyFormatSARIF))
+	assert.EqualError(t, err, "conjure verify failed")
+	var sarifLog struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(outputBuf.Bytes(), &sarifLog))
+	assert.Equal(t, "2.1.0", sarifLog.Version)
+	require.Len(t, sarifLog.Runs, 1)
+	var got []string
+	for _, result := range sarifLog.Runs[0].Results {
+		require.Len(t, result.Locations, 1)
+		got = append(got, result.RuleID+" "+result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	}
+	assert.Equal(t, []string{
+		"conjure-missing-file conjure/com/palantir/bar/structs.conjure.go",
+		"conjure-changed-file conjure/com/palantir/foo/structs.conjure.go",
+		"conjure-extra-file conjure/com/palantir/baz/structs.conjure.go",
+	}, got)
+}
+
+func TestRunSelectedProjects(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, "go.mod"), []byte("module github.com/palantir/test\n"), 0644))
+	params := conjureplugin.ConjureProjectParams{
+		Params: make(map[string]conjureplugin.ConjureProjectParam),
+	}
+	for _, name := range []string{"foo", "bar"} {
+		yamlPath := filepath.Join(projectDir, name+".yml")
+		require.NoError(t, ioutil.WriteFile(yamlPath, []byte(fmt.Sprintf("types:\n  definitions:\n    default-package: com.palantir.%s\n    objects:\n      Object:\n        fields:\n          value: string\n", name)), 0644))
+		params.SortedKeys = append(params.SortedKeys, name)
+		params.Params[name] = conjureplugin.ConjureProjectParam{
+			// the output directory of bar is nested in the output directory of foo
+			OutputDir:  map[string]string{"foo": "conjure", "bar": "conjure/bar"}[name],
+			IRProvider: conjureplugin.NewLocalYAMLIRProvider(yamlPath, conjureplugin.YAMLCompilerParam(conjureplugin.YAMLCompilerNative)),
+		}
+	}
+	lockfilePath := filepath.Join(projectDir, conjureplugin.LockfileName)
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, conjureplugin.LockfileParam(lockfilePath)))
+	fullLockfile, _, err := conjureplugin.ReadLockfile(lockfilePath)
+	require.NoError(t, err)
+	require.Len(t, fullLockfile.Projects, 2)
+
+	// running only foo preserves the lockfile entry and the generated files of bar
+	barFile := filepath.Join(projectDir, "conjure", "bar", "com", "palantir", "bar", "structs.conjure.go")
+	require.FileExists(t, barFile)
+	selectFoo := conjureplugin.ProjectsParam([]string{"f*"}, nil)
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, conjureplugin.LockfileParam(lockfilePath), selectFoo))
+	assert.FileExists(t, barFile)
+	lockfile, _, err := conjureplugin.ReadLockfile(lockfilePath)
+	require.NoError(t, err)
+	assert.Equal(t, fullLockfile, lockfile)
+
+	// verifying only foo ignores the differences of bar, which are reported using the index of bar among all projects
+	require.NoError(t, ioutil.WriteFile(barFile, []byte("package bar\n"), 0644))
+	require.NoError(t, conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}, conjureplugin.LockfileParam(lockfilePath), selectFoo))
+	outputBuf := &bytes.Buffer{}
+	err = conjureplugin.Run(params, true, projectDir, outputBuf, conjureplugin.LockfileParam(lockfilePath), conjureplugin.ProjectsParam(nil, []string{"foo"}))
+	assert.EqualError(t, err, "conjure verify failed")
+	assert.Contains(t, outputBuf.String(), "Conjure output differs from what currently exists: [1]\n")
+
+	err = conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, conjureplugin.ProjectsParam([]string{"baz"}, nil))
+	assert.EqualError(t, err, `project "baz" does not match any project: valid projects are foo, bar`)
+}
+
+func TestRunSkipsUpToDateProjects(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, "go.mod"), []byte("module github.com/palantir/test\n"), 0644))
+	yamlPath := filepath.Join(projectDir, "api.yml")
+	require.NoError(t, ioutil.WriteFile(yamlPath, []byte(`
+types:
+  definitions:
+    default-package: com.palantir.foo
+    objects:
+      Foo:
+        fields:
+          value: string
+`), 0644))
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project": {
+				OutputDir:  "conjure",
+				IRProvider: conjureplugin.NewLocalYAMLIRProvider(yamlPath, conjureplugin.YAMLCompilerParam(conjureplugin.YAMLCompilerNative)),
+			},
+		},
+	}
+	stateFile := conjureplugin.StateFileParam(filepath.Join(projectDir, "build", conjureplugin.StateFileName))
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, stateFile))
+	require.FileExists(t, filepath.Join(projectDir, "build", conjureplugin.StateFileName))
+
+	// a file that is written by generation has a new modification time
+	structsFile := filepath.Join(projectDir, "conjure", "com", "palantir", "foo", "structs.conjure.go")
+	oldTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	wasGenerated := func() bool {
+		fi, err := os.Stat(structsFile)
+		require.NoError(t, err)
+		generated := !fi.ModTime().Equal(oldTime)
+		require.NoError(t, os.Chtimes(structsFile, oldTime, oldTime))
+		return generated
+	}
+	require.True(t, wasGenerated())
+
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, stateFile))
+	assert.False(t, wasGenerated(), "project was generated although its output is up to date")
+
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, stateFile, conjureplugin.ForceParam(true)))
+	assert.True(t, wasGenerated(), "project was not generated with force")
+
+	content, err := ioutil.ReadFile(structsFile)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(structsFile, []byte("package foo\n"), 0644))
+	require.NoError(t, os.Chtimes(structsFile, oldTime, oldTime))
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, stateFile))
+	assert.True(t, wasGenerated(), "project was not generated although its output was modified")
+	got, err := ioutil.ReadFile(structsFile)
+	require.NoError(t, err)
+	assert.Equal(t, string(content), string(got))
+
+	serverParams := conjureplugin.ConjureProjectParams{
+		SortedKeys: params.SortedKeys,
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project": {
+				OutputDir:  "conjure",
+				IRProvider: params.Params["project"].IRProvider,
+				Server:     true,
+			},
+		},
+	}
+	require.NoError(t, conjureplugin.Run(serverParams, false, projectDir, &bytes.Buffer{}, stateFile))
+	assert.True(t, wasGenerated(), "project was not generated although its output configuration changed")
+
+	require.NoError(t, ioutil.WriteFile(yamlPath, []byte(`
+types:
+  definitions:
+    default-package: com.palantir.foo
+    objects:
+      Foo:
+        fields:
+          value: integer
+`), 0644))
+	require.NoError(t, conjureplugin.Run(serverParams, false, projectDir, &bytes.Buffer{}, stateFile))
+	assert.True(t, wasGenerated(), "project was not generated although its IR changed")
+}
+
+func TestRunDryRun(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, "go.mod"), []byte("module github.com/palantir/test\n"), 0644))
+	yamlPath := filepath.Join(projectDir, "api.yml")
+	writeYAML := func(packages map[string]string) {
+		yml := "types:\n  definitions:\n    objects:\n"
+		for _, pkg := range []string{"bar", "baz", "foo", "qux"} {
+			if fieldType, ok := packages[pkg]; ok {
+				yml += fmt.Sprintf("      %s:\n        package: com.palantir.%s\n        fields:\n          value: %s\n", strings.ToUpper(pkg[:1])+pkg[1:], pkg, fieldType)
+			}
+		}
+		require.NoError(t, ioutil.WriteFile(yamlPath, []byte(yml), 0644))
+	}
+	writeYAML(map[string]string{"bar": "string", "foo": "string", "qux": "string"})
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project": {
+				OutputDir:  "conjure",
+				IRProvider: conjureplugin.NewLocalYAMLIRProvider(yamlPath, conjureplugin.YAMLCompilerParam(conjureplugin.YAMLCompilerNative)),
+			},
+		},
+	}
+	lockfilePath := filepath.Join(projectDir, conjureplugin.LockfileName)
+	stateFilePath := filepath.Join(projectDir, "build", conjureplugin.StateFileName)
+	runParams := []conjureplugin.RunParam{
+		conjureplugin.LockfileParam(lockfilePath),
+		conjureplugin.StateFileParam(stateFilePath),
+	}
+
+	stdout := &bytes.Buffer{}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, stdout, append(runParams, conjureplugin.DryRunParam(true))...))
+	assert.Equal(t, `Conjure project project (dry run):
+  create    conjure/com/palantir/bar/structs.conjure.go
+  create    conjure/com/palantir/foo/structs.conjure.go
+  create    conjure/com/palantir/qux/structs.conjure.go
+`, stdout.String())
+	for _, p := range []string{"conjure", conjureplugin.LockfileName, "build"} {
+		_, err := os.Stat(filepath.Join(projectDir, p))
+		assert.True(t, os.IsNotExist(err), "%s was written by a dry run", p)
+	}
+
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, runParams...))
+	lockfileContent, err := ioutil.ReadFile(lockfilePath)
+	require.NoError(t, err)
+	fooContent, err := ioutil.ReadFile(filepath.Join(projectDir, "conjure", "com", "palantir", "foo", "structs.conjure.go"))
+	require.NoError(t, err)
+
+	writeYAML(map[string]string{"baz": "string", "foo": "integer", "qux": "string"})
+	stdout = &bytes.Buffer{}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, stdout, append(runParams, conjureplugin.DryRunParam(true))...))
+	assert.Equal(t, `Conjure project project (dry run):
+  delete    conjure/com/palantir/bar/structs.conjure.go
+  create    conjure/com/palantir/baz/structs.conjure.go
+  modify    conjure/com/palantir/foo/structs.conjure.go
+  unchanged conjure/com/palantir/qux/structs.conjure.go
+`, stdout.String())
+	assert.FileExists(t, filepath.Join(projectDir, "conjure", "com", "palantir", "bar", "structs.conjure.go"))
+	assert.NoDirExists(t, filepath.Join(projectDir, "conjure", "com", "palantir", "baz"))
+	gotFooContent, err := ioutil.ReadFile(filepath.Join(projectDir, "conjure", "com", "palantir", "foo", "structs.conjure.go"))
+	require.NoError(t, err)
+	assert.Equal(t, string(fooContent), string(gotFooContent))
+	gotLockfileContent, err := ioutil.ReadFile(lockfilePath)
+	require.NoError(t, err)
+	assert.Equal(t, string(lockfileContent), string(gotLockfileContent))
+
+	err = conjureplugin.Run(params, true, projectDir, &bytes.Buffer{}, append(runParams, conjureplugin.DryRunParam(true))...)
+	assert.EqualError(t, err, "dry run cannot be used when verifying")
+}
+
+func TestRunDefinitionFilter(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, "go.mod"), []byte("module github.com/palantir/test\n"), 0644))
+	yamlPath := filepath.Join(projectDir, "api.yml")
+	require.NoError(t, ioutil.WriteFile(yamlPath, []byte(`
+types:
+  definitions:
+    objects:
+      Foo:
+        package: com.palantir.foo
+        fields:
+          bar: Bar
+      Bar:
+        package: com.palantir.bar
+        fields:
+          value: string
+      Baz:
+        package: com.palantir.baz
+        fields:
+          value: string
+`), 0644))
+	param := conjureplugin.ConjureProjectParam{
+		OutputDir:  "conjure",
+		IRProvider: conjureplugin.NewLocalYAMLIRProvider(yamlPath, conjureplugin.YAMLCompilerParam(conjureplugin.YAMLCompilerNative)),
+		DefinitionFilter: conjureplugin.DefinitionFilter{
+			Include: []conjureplugin.DefinitionSelector{{Package: "com.palantir.foo"}},
+		},
+	}
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project"},
+		Params:     map[string]conjureplugin.ConjureProjectParam{"project": param},
+	}
+	stateFile := conjureplugin.StateFileParam(filepath.Join(projectDir, "build", conjureplugin.StateFileName))
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, stateFile))
+	assert.FileExists(t, filepath.Join(projectDir, "conjure", "com", "palantir", "foo", "structs.conjure.go"))
+	assert.FileExists(t, filepath.Join(projectDir, "conjure", "com", "palantir", "bar", "structs.conjure.go"))
+	assert.NoDirExists(t, filepath.Join(projectDir, "conjure", "com", "palantir", "baz"))
+
+	// a project whose filter changed is generated again even though its IR did not change
+	param.DefinitionFilter = conjureplugin.DefinitionFilter{}
+	params.Params["project"] = param
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, stateFile))
+	assert.FileExists(t, filepath.Join(projectDir, "conjure", "com", "palantir", "baz", "structs.conjure.go"))
+}
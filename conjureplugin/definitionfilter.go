@@ -0,0 +1,303 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+	"github.com/pkg/errors"
+)
+
+// DefinitionSelector selects types, errors and services of a Conjure definition. Every value that is specified is a
+// pattern in the syntax of path.Match that must match the corresponding part of the name of a definition. Package
+// matches the package of types, errors and services, Type matches the name of types and errors and Service matches the
+// name of services. A selector that specifies Type does not select services and a selector that specifies Service does
+// not select types or errors.
+type DefinitionSelector struct {
+	Package string
+	Type    string
+	Service string
+}
+
+// Validate returns an error if the selector does not specify any value, specifies both Type and Service or specifies an
+// invalid pattern.
+func (s DefinitionSelector) Validate() error {
+	if s.Package == "" && s.Type == "" && s.Service == "" {
+		return errors.Errorf("selector must specify at least one of package, type or service")
+	}
+	if s.Type != "" && s.Service != "" {
+		return errors.Errorf("selector %s cannot specify both type and service", s)
+	}
+	for _, pattern := range []string{s.Package, s.Type, s.Service} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.Wrapf(err, "invalid pattern %q in selector %s", pattern, s)
+		}
+	}
+	return nil
+}
+
+func (s DefinitionSelector) String() string {
+	var parts []string
+	for _, curr := range []struct {
+		name, pattern string
+	}{
+		{"package", s.Package},
+		{"type", s.Type},
+		{"service", s.Service},
+	} {
+		if curr.pattern != "" {
+			parts = append(parts, fmt.Sprintf("%s=%s", curr.name, curr.pattern))
+		}
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func (s DefinitionSelector) matchesType(name spec.TypeName) bool {
+	return s.Service == "" && matchesPattern(s.Package, name.Package) && matchesPattern(s.Type, name.Name)
+}
+
+func (s DefinitionSelector) matchesService(name spec.TypeName) bool {
+	return s.Type == "" && matchesPattern(s.Package, name.Package) && matchesPattern(s.Service, name.Name)
+}
+
+// matchesPattern returns true if the provided pattern is empty or matches the provided value. The pattern must be
+// valid.
+func matchesPattern(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, value)
+	return ok
+}
+
+// DefinitionFilter selects the types, errors and services of a Conjure definition that are generated. If Include is
+// non-empty, only the definitions that match at least one of its selectors are selected. Definitions that match any of
+// the selectors of Exclude are not selected. Every type that is referenced by a selected definition is generated even
+// if it is not selected itself, so the generated code always compiles.
+type DefinitionFilter struct {
+	Include []DefinitionSelector
+	Exclude []DefinitionSelector
+}
+
+// IsEmpty returns true if the filter selects every definition.
+func (f DefinitionFilter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+func (f DefinitionFilter) String() string {
+	if f.IsEmpty() {
+		return ""
+	}
+	var include, exclude []string
+	for _, s := range f.Include {
+		include = append(include, s.String())
+	}
+	for _, s := range f.Exclude {
+		exclude = append(exclude, s.String())
+	}
+	return fmt.Sprintf("include=[%s] exclude=[%s]", strings.Join(include, " "), strings.Join(exclude, " "))
+}
+
+// Apply returns the provided definition with only the types, errors and services that are selected by the filter and
+// the types that they reference, directly or transitively. The order of the remaining definitions is preserved.
+// Returns an error if a selector of Include does not match any definition.
+func (f DefinitionFilter) Apply(def spec.ConjureDefinition) (spec.ConjureDefinition, error) {
+	if f.IsEmpty() {
+		return def, nil
+	}
+	for _, s := range append(append([]DefinitionSelector{}, f.Include...), f.Exclude...) {
+		if err := s.Validate(); err != nil {
+			return spec.ConjureDefinition{}, err
+		}
+	}
+
+	typeNames := make([]spec.TypeName, len(def.Types))
+	typeRefs := make(map[spec.TypeName][]spec.TypeName)
+	for i, typeDef := range def.Types {
+		var refs []spec.TypeName
+		typeNames[i], refs = typeDefinitionReferences(typeDef)
+		typeRefs[typeNames[i]] = refs
+	}
+
+	matched := make([]bool, len(f.Include))
+	selected := func(name spec.TypeName, matches func(DefinitionSelector, spec.TypeName) bool) bool {
+		included := len(f.Include) == 0
+		for i, s := range f.Include {
+			if matches(s, name) {
+				matched[i] = true
+				included = true
+			}
+		}
+		if !included {
+			return false
+		}
+		for _, s := range f.Exclude {
+			if matches(s, name) {
+				return false
+			}
+		}
+		return true
+	}
+
+	keepTypes := make(map[spec.TypeName]struct{})
+	var keep func(refs []spec.TypeName)
+	keep = func(refs []spec.TypeName) {
+		for _, ref := range refs {
+			if _, ok := keepTypes[ref]; ok {
+				continue
+			}
+			keepTypes[ref] = struct{}{}
+			keep(typeRefs[ref])
+		}
+	}
+
+	filtered := spec.ConjureDefinition{
+		Version:    def.Version,
+		Extensions: def.Extensions,
+	}
+	for _, name := range typeNames {
+		if selected(name, DefinitionSelector.matchesType) {
+			keep([]spec.TypeName{name})
+		}
+	}
+	for _, errorDef := range def.Errors {
+		if !selected(errorDef.ErrorName, DefinitionSelector.matchesType) {
+			continue
+		}
+		filtered.Errors = append(filtered.Errors, errorDef)
+		keep(fieldTypeReferences(errorDef.SafeArgs))
+		keep(fieldTypeReferences(errorDef.UnsafeArgs))
+	}
+	for _, serviceDef := range def.Services {
+		if !selected(serviceDef.ServiceName, DefinitionSelector.matchesService) {
+			continue
+		}
+		filtered.Services = append(filtered.Services, serviceDef)
+		for _, endpoint := range serviceDef.Endpoints {
+			if endpoint.Returns != nil {
+				keep(typeReferences(*endpoint.Returns))
+			}
+			keep(typeReferences(endpoint.Markers...))
+			for _, arg := range endpoint.Args {
+				keep(typeReferences(arg.Type))
+				keep(typeReferences(arg.Markers...))
+			}
+		}
+	}
+	for i, typeDef := range def.Types {
+		if _, ok := keepTypes[typeNames[i]]; ok {
+			filtered.Types = append(filtered.Types, typeDef)
+		}
+	}
+
+	for i, s := range f.Include {
+		if !matched[i] {
+			return spec.ConjureDefinition{}, errors.Errorf("include selector %s does not match any type, error or service", s)
+		}
+	}
+	return filtered, nil
+}
+
+// typeReferences returns the names of the types that are referenced by the provided types, including the fallbacks of
+// external references.
+func typeReferences(types ...spec.Type) []spec.TypeName {
+	v := &typeReferenceVisitor{}
+	for _, t := range types {
+		_ = t.Accept(v)
+	}
+	return v.refs
+}
+
+// typeDefinitionReferences returns the name of the provided type definition and the names of the types that it
+// references.
+func typeDefinitionReferences(typeDef spec.TypeDefinition) (spec.TypeName, []spec.TypeName) {
+	v := &typeDefinitionReferenceVisitor{}
+	_ = typeDef.Accept(v)
+	return v.typeName, v.refs
+}
+
+type typeReferenceVisitor struct {
+	refs []spec.TypeName
+}
+
+func (v *typeReferenceVisitor) VisitPrimitive(spec.PrimitiveType) error {
+	return nil
+}
+
+func (v *typeReferenceVisitor) VisitOptional(t spec.OptionalType) error {
+	return t.ItemType.Accept(v)
+}
+
+func (v *typeReferenceVisitor) VisitList(t spec.ListType) error {
+	return t.ItemType.Accept(v)
+}
+
+func (v *typeReferenceVisitor) VisitSet(t spec.SetType) error {
+	return t.ItemType.Accept(v)
+}
+
+func (v *typeReferenceVisitor) VisitMap(t spec.MapType) error {
+	if err := t.KeyType.Accept(v); err != nil {
+		return err
+	}
+	return t.ValueType.Accept(v)
+}
+
+func (v *typeReferenceVisitor) VisitReference(t spec.TypeName) error {
+	v.refs = append(v.refs, t)
+	return nil
+}
+
+func (v *typeReferenceVisitor) VisitExternal(t spec.ExternalReference) error {
+	return t.Fallback.Accept(v)
+}
+
+func (v *typeReferenceVisitor) VisitUnknown(string) error {
+	return nil
+}
+
+// typeDefinitionReferenceVisitor records the name of a type definition using the embedded typeNameVisitor and the
+// names of the types that the definition references.
+type typeDefinitionReferenceVisitor struct {
+	typeNameVisitor
+	refs []spec.TypeName
+}
+
+func (v *typeDefinitionReferenceVisitor) VisitAlias(def spec.AliasDefinition) error {
+	v.refs = typeReferences(def.Alias)
+	return v.typeNameVisitor.VisitAlias(def)
+}
+
+func (v *typeDefinitionReferenceVisitor) VisitObject(def spec.ObjectDefinition) error {
+	v.refs = fieldTypeReferences(def.Fields)
+	return v.typeNameVisitor.VisitObject(def)
+}
+
+func (v *typeDefinitionReferenceVisitor) VisitUnion(def spec.UnionDefinition) error {
+	v.refs = fieldTypeReferences(def.Union)
+	return v.typeNameVisitor.VisitUnion(def)
+}
+
+// fieldTypeReferences returns the names of the types that are referenced by the types of the provided fields.
+func fieldTypeReferences(fields []spec.FieldDefinition) []spec.TypeName {
+	var types []spec.Type
+	for _, field := range fields {
+		types = append(types, field.Type)
+	}
+	return typeReferences(types...)
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"testing"
+
+	"github.com/palantir/conjure-go/v6/conjure"
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const definitionFilterTestIR = `{
+  "version": 1,
+  "errors": [
+    {
+      "errorName": {"name": "NotFound", "package": "com.palantir.api"},
+      "namespace": "Api",
+      "code": "NOT_FOUND",
+      "safeArgs": [{"fieldName": "arg", "type": {"type": "reference", "reference": {"name": "ErrorArg", "package": "com.palantir.other"}}}],
+      "unsafeArgs": []
+    }
+  ],
+  "types": [
+    {"type": "object", "object": {"typeName": {"name": "Request", "package": "com.palantir.api"}, "fields": [
+      {"fieldName": "inner", "type": {"type": "list", "list": {"itemType": {"type": "reference", "reference": {"name": "Inner", "package": "com.palantir.api"}}}}},
+      {"fieldName": "external", "type": {"type": "external", "external": {"externalReference": {"name": "External", "package": "com.palantir.external"}, "fallback": {"type": "reference", "reference": {"name": "Fallback", "package": "com.palantir.api"}}}}}
+    ]}},
+    {"type": "alias", "alias": {"typeName": {"name": "Inner", "package": "com.palantir.api"}, "alias": {"type": "map", "map": {"keyType": {"type": "primitive", "primitive": "STRING"}, "valueType": {"type": "reference", "reference": {"name": "Kind", "package": "com.palantir.api"}}}}}},
+    {"type": "enum", "enum": {"typeName": {"name": "Kind", "package": "com.palantir.api"}, "values": [{"value": "A"}]}},
+    {"type": "alias", "alias": {"typeName": {"name": "Fallback", "package": "com.palantir.api"}, "alias": {"type": "primitive", "primitive": "STRING"}}},
+    {"type": "object", "object": {"typeName": {"name": "Marker", "package": "com.palantir.api"}, "fields": []}},
+    {"type": "object", "object": {"typeName": {"name": "Unused", "package": "com.palantir.api"}, "fields": []}},
+    {"type": "union", "union": {"typeName": {"name": "Other", "package": "com.palantir.other"}, "union": [
+      {"fieldName": "kind", "type": {"type": "reference", "reference": {"name": "Kind", "package": "com.palantir.api"}}}
+    ]}},
+    {"type": "object", "object": {"typeName": {"name": "ErrorArg", "package": "com.palantir.other"}, "fields": []}}
+  ],
+  "services": [
+    {"serviceName": {"name": "ApiService", "package": "com.palantir.api"}, "endpoints": [
+      {
+        "endpointName": "get",
+        "httpMethod": "POST",
+        "httpPath": "/get",
+        "args": [{"argName": "request", "type": {"type": "reference", "reference": {"name": "Request", "package": "com.palantir.api"}}, "paramType": {"type": "body", "body": {}}, "markers": [], "tags": []}],
+        "returns": {"type": "optional", "optional": {"itemType": {"type": "primitive", "primitive": "STRING"}}},
+        "markers": [{"type": "reference", "reference": {"name": "Marker", "package": "com.palantir.api"}}],
+        "tags": []
+      }
+    ]},
+    {"serviceName": {"name": "OtherService", "package": "com.palantir.other"}, "endpoints": [
+      {
+        "endpointName": "get",
+        "httpMethod": "GET",
+        "httpPath": "/other",
+        "args": [],
+        "returns": {"type": "reference", "reference": {"name": "Other", "package": "com.palantir.other"}},
+        "markers": [],
+        "tags": []
+      }
+    ]}
+  ],
+  "extensions": {}
+}`
+
+func TestDefinitionFilterApply(t *testing.T) {
+	def, err := conjure.FromIRBytes([]byte(definitionFilterTestIR))
+	require.NoError(t, err)
+
+	for i, tc := range []struct {
+		name         string
+		filter       conjureplugin.DefinitionFilter
+		wantTypes    []string
+		wantErrors   []string
+		wantServices []string
+	}{
+		{
+			name:         "empty filter selects everything",
+			wantTypes:    []string{"com.palantir.api.Request", "com.palantir.api.Inner", "com.palantir.api.Kind", "com.palantir.api.Fallback", "com.palantir.api.Marker", "com.palantir.api.Unused", "com.palantir.other.Other", "com.palantir.other.ErrorArg"},
+			wantErrors:   []string{"com.palantir.api.NotFound"},
+			wantServices: []string{"com.palantir.api.ApiService", "com.palantir.other.OtherService"},
+		},
+		{
+			name: "service and its transitive types",
+			filter: conjureplugin.DefinitionFilter{
+				Include: []conjureplugin.DefinitionSelector{{Service: "ApiService"}},
+			},
+			wantTypes:    []string{"com.palantir.api.Request", "com.palantir.api.Inner", "com.palantir.api.Kind", "com.palantir.api.Fallback", "com.palantir.api.Marker"},
+			wantServices: []string{"com.palantir.api.ApiService"},
+		},
+		{
+			name: "package",
+			filter: conjureplugin.DefinitionFilter{
+				Include: []conjureplugin.DefinitionSelector{{Package: "com.palantir.oth*"}},
+			},
+			wantTypes:    []string{"com.palantir.api.Kind", "com.palantir.other.Other", "com.palantir.other.ErrorArg"},
+			wantServices: []string{"com.palantir.other.OtherService"},
+		},
+		{
+			name: "error and its types",
+			filter: conjureplugin.DefinitionFilter{
+				Include: []conjureplugin.DefinitionSelector{{Type: "NotFound"}},
+			},
+			wantTypes:  []string{"com.palantir.other.ErrorArg"},
+			wantErrors: []string{"com.palantir.api.NotFound"},
+		},
+		{
+			name: "excluded types that are referenced are kept",
+			filter: conjureplugin.DefinitionFilter{
+				Exclude: []conjureplugin.DefinitionSelector{{Package: "com.palantir.api"}},
+			},
+			wantTypes:    []string{"com.palantir.api.Kind", "com.palantir.other.Other", "com.palantir.other.ErrorArg"},
+			wantServices: []string{"com.palantir.other.OtherService"},
+		},
+		{
+			name: "type selectors do not select services",
+			filter: conjureplugin.DefinitionFilter{
+				Include: []conjureplugin.DefinitionSelector{{Package: "com.palantir.api", Type: "*"}},
+				Exclude: []conjureplugin.DefinitionSelector{{Type: "Unused"}, {Type: "NotFound"}},
+			},
+			wantTypes: []string{"com.palantir.api.Request", "com.palantir.api.Inner", "com.palantir.api.Kind", "com.palantir.api.Fallback", "com.palantir.api.Marker"},
+		},
+	} {
+		got, err := tc.filter.Apply(def)
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		var gotTypes, gotErrors, gotServices []string
+		for _, typeDef := range got.Types {
+			name, err := conjureplugin.TypeDefinitionName(typeDef)
+			require.NoError(t, err, "Case %d: %s", i, tc.name)
+			gotTypes = append(gotTypes, name)
+		}
+		for _, errorDef := range got.Errors {
+			gotErrors = append(gotErrors, errorDef.ErrorName.Package+"."+errorDef.ErrorName.Name)
+		}
+		for _, serviceDef := range got.Services {
+			gotServices = append(gotServices, serviceDef.ServiceName.Package+"."+serviceDef.ServiceName.Name)
+		}
+		assert.Equal(t, tc.wantTypes, gotTypes, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.wantErrors, gotErrors, "Case %d: %s", i, tc.name)
+		assert.Equal(t, tc.wantServices, gotServices, "Case %d: %s", i, tc.name)
+	}
+
+	_, err = conjureplugin.DefinitionFilter{
+		Include: []conjureplugin.DefinitionSelector{{Service: "ApiService"}, {Package: "com.palantir.missing"}},
+	}.Apply(def)
+	assert.EqualError(t, err, "include selector {package=com.palantir.missing} does not match any type, error or service")
+}
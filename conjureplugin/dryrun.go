@@ -0,0 +1,171 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/palantir/conjure-go/v6/conjure"
+	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+	"github.com/pkg/errors"
+)
+
+const (
+	dryRunCreate    = "create"
+	dryRunModify    = "modify"
+	dryRunUnchanged = "unchanged"
+	dryRunDelete    = "delete"
+)
+
+// printDryRun prints the operation that generation would perform on every file of the provided project. The result
+// must have been computed in verify mode, and the difference must include the stale files of the project.
+func printDryRun(stdout io.Writer, key string, result projectResult, absProjectDir string) error {
+	operations := make(map[string]string)
+	for _, generatedPath := range result.generatedPaths {
+		relPath, err := filepath.Rel(absProjectDir, generatedPath)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		operations[relPath] = dryRunUnchanged
+	}
+	for relPath, desc := range result.verifyDiff.Diffs {
+		switch desc {
+		case "extra":
+			operations[relPath] = dryRunCreate
+		case "missing", staleFileDiff:
+			operations[relPath] = dryRunDelete
+		default:
+			operations[relPath] = dryRunModify
+		}
+	}
+	var relPaths []string
+	for relPath := range operations {
+		relPaths = append(relPaths, relPath)
+	}
+	sort.Strings(relPaths)
+
+	_, _ = fmt.Fprintf(stdout, "Conjure project %s (dry run):\n", key)
+	for _, relPath := range relPaths {
+		_, _ = fmt.Fprintf(stdout, "%s%-*s %s\n", strings.Repeat(" ", indentLen), len(dryRunUnchanged), operations[relPath], filepath.ToSlash(relPath))
+	}
+	return nil
+}
+
+// dryRunGeneratedPaths returns the absolute paths of the files that are generated for the provided definition with
+// the provided configuration, whose output directory does not exist. conjure-go creates the output directory to
+// determine its Go package, so the files are instead generated for a temporary directory that is the root of a module
+// whose path is the package path that the output directory would have, and their paths are mapped to the output
+// directory.
+func dryRunGeneratedPaths(conjureDef spec.ConjureDefinition, outputConf conjure.OutputConfiguration) (rPaths []string, rErr error) {
+	outputDir, err := filepath.Abs(outputConf.OutputDir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// the package path of the output directory is determined by its closest ancestor that exists
+	existingDir, missingRelPath := outputDir, "."
+	for {
+		if _, err := os.Stat(existingDir); err == nil {
+			break
+		} else if !os.IsNotExist(err) {
+			return nil, errors.WithStack(err)
+		}
+		parentDir := filepath.Dir(existingDir)
+		if parentDir == existingDir {
+			return nil, errors.Errorf("no ancestor of output directory %s exists", outputDir)
+		}
+		missingRelPath = filepath.Join(filepath.Base(existingDir), missingRelPath)
+		existingDir = parentDir
+	}
+	modPath, modDir, err := goModule(existingDir)
+	if err != nil {
+		return nil, err
+	}
+	if modPath == "" {
+		return nil, errors.Errorf("output directory %s does not exist and is not in a Go module", outputDir)
+	}
+	normalizedExistingDir, err := filepath.EvalSymlinks(existingDir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	normalizedModDir, err := filepath.EvalSymlinks(modDir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	existingRelPath, err := filepath.Rel(normalizedModDir, normalizedExistingDir)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	pkgPath := path.Join(modPath, filepath.ToSlash(existingRelPath), filepath.ToSlash(missingRelPath))
+
+	tmpDir, err := ioutil.TempDir("", "conjure-dry-run-")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create temporary directory")
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); rErr == nil && err != nil {
+			rErr = errors.Wrapf(err, "failed to remove temporary directory")
+		}
+	}()
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module "+pkgPath+"\n"), 0644); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	tmpOutputConf := outputConf
+	tmpOutputConf.OutputDir = tmpDir
+	files, err := conjure.GenerateOutputFiles(conjureDef, tmpOutputConf)
+	if err != nil {
+		return nil, err
+	}
+	var generatedPaths []string
+	for _, file := range files {
+		relPath, err := filepath.Rel(tmpDir, file.AbsPath())
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		generatedPaths = append(generatedPaths, filepath.Join(outputDir, relPath))
+	}
+	return generatedPaths, nil
+}
+
+// goModule returns the path and the root directory of the Go module of the provided directory as determined by
+// "go list -m", which is how conjure-go determines the package of an output directory. Returns empty strings if the
+// directory is not in a module.
+func goModule(dir string) (string, string, error) {
+	cmd := exec.Command("go", "list", "-m", "-mod=readonly", "-json")
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if string(output) == "go list -m: not using modules\n" {
+			return "", "", nil
+		}
+		return "", "", errors.Wrapf(err, "failed to determine the Go module of %s: %s", dir, strings.TrimSpace(string(output)))
+	}
+	var module struct {
+		Path string
+		Dir  string
+	}
+	if err := json.Unmarshal(output, &module); err != nil {
+		return "", "", errors.Wrapf(err, "failed to determine the Go module of %s", dir)
+	}
+	return module.Path, module.Dir, nil
+}
@@ -0,0 +1,376 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli"
+	"github.com/pkg/errors"
+)
+
+var _ IRProvider = &gitIRProvider{}
+var _ LocatorResolver = &gitIRProvider{}
+var _ InputDigester = &gitIRProvider{}
+
+type gitIRProvider struct {
+	repoURL    string
+	ref        string
+	subpath    string
+	yamlParams []YAMLParam
+	remoteConfig
+
+	// mu guards resolved, which is computed from a single checkout the first time that it is needed so that the IR, the
+	// resolved locator and the input digest always correspond to the same commit.
+	mu       sync.Mutex
+	resolved *gitResolution
+}
+
+// gitResolution is the IR provided by a Git repository at a specific commit.
+type gitResolution struct {
+	commit      string
+	irBytes     []byte
+	inputDigest string
+}
+
+// NewGitIRProvider returns an IRProvider that provides IR from a path in a Git repository at a specific ref (a tag,
+// branch or commit). The repository can be any URL or path supported by "git clone", including a local bare
+// repository. The repository is checked out in the "git" directory of the cache if one is configured and in a
+// temporary directory otherwise. If the subpath in the checkout is a directory or a YAML file, the IR is generated from
+// the YAML using the provided YAML parameters; otherwise, the subpath is treated as an IR file.
+func NewGitIRProvider(repoURL, ref, subpath string, yamlParams []YAMLParam, params ...RemoteParam) (IRProvider, error) {
+	if ref == "" {
+		return nil, errors.Errorf("ref must be specified for Git repository %s", gitDisplayURL(repoURL))
+	}
+	if _, ok := relPathWithin(".", subpath); !ok || filepath.IsAbs(subpath) {
+		return nil, errors.Errorf("path %s must be a relative path within the repository", subpath)
+	}
+	return &gitIRProvider{
+		repoURL:      repoURL,
+		ref:          ref,
+		subpath:      subpath,
+		yamlParams:   yamlParams,
+		remoteConfig: newRemoteConfig(params...),
+	}, nil
+}
+
+func (p *gitIRProvider) IRBytes() ([]byte, error) {
+	resolved, err := p.resolve()
+	if err != nil {
+		return nil, err
+	}
+	return resolved.irBytes, nil
+}
+
+// GeneratedFromYAML returns false. Although the IR may be generated from YAML, the YAML is not local to the project,
+// so the provider is treated like other non-local sources for the purposes of publishing and pinning.
+func (p *gitIRProvider) GeneratedFromYAML() bool {
+	return false
+}
+
+func (p *gitIRProvider) ResolvedLocator() (string, error) {
+	resolved, err := p.resolve()
+	if err != nil {
+		return "", err
+	}
+	return gitDisplayURL(p.repoURL) + "@" + resolved.commit + ":" + filepath.ToSlash(p.subpath), nil
+}
+
+func (p *gitIRProvider) InputDigest() (string, error) {
+	resolved, err := p.resolve()
+	if err != nil {
+		return "", err
+	}
+	return resolved.inputDigest, nil
+}
+
+// resolve returns the IR of the provider. The ref is resolved and checked out the first time that resolve succeeds, and
+// the result is reused afterwards.
+func (p *gitIRProvider) resolve() (*gitResolution, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resolved != nil {
+		return p.resolved, nil
+	}
+	var resolved gitResolution
+	if err := p.withCheckout(func(checkoutDir, commit string) error {
+		provider, err := p.delegate(checkoutDir)
+		if err != nil {
+			return err
+		}
+		if resolved.irBytes, err = provider.IRBytes(); err != nil {
+			return err
+		}
+		if resolved.inputDigest, err = inputDigest(provider); err != nil {
+			return err
+		}
+		resolved.commit = commit
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	p.resolved = &resolved
+	return p.resolved, nil
+}
+
+// delegate returns the provider for the subpath of the provided checkout directory.
+func (p *gitIRProvider) delegate(checkoutDir string) (IRProvider, error) {
+	targetPath := filepath.Join(checkoutDir, p.subpath)
+	fi, err := os.Stat(targetPath)
+	if os.IsNotExist(err) {
+		return nil, errors.Errorf("path %s does not exist in Git repository %s at %s", p.subpath, gitDisplayURL(p.repoURL), p.ref)
+	} else if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if fi.IsDir() || isYAMLFile(targetPath) {
+		return NewLocalYAMLIRProvider(targetPath, p.yamlParams...), nil
+	}
+	return NewLocalFileIRProvider(targetPath), nil
+}
+
+var gitCommitRegexp = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// withCheckout checks out the ref of the provider and invokes the provided function with the checkout directory and
+// the commit of the ref. The checkout directory must not be modified.
+func (p *gitIRProvider) withCheckout(fn func(checkoutDir, commit string) error) (rErr error) {
+	baseDir := ""
+	if p.cache != nil {
+		baseDir = filepath.Join(p.cache.dir, "git", sha256Digest([]byte(p.repoURL)))
+	} else {
+		if p.offline {
+			return errors.Errorf("Git repository %s cannot be cloned in offline mode", gitDisplayURL(p.repoURL))
+		}
+		tmpDir, err := ioutil.TempDir("", "conjure-git-")
+		if err != nil {
+			return errors.Wrapf(err, "failed to create temporary directory")
+		}
+		defer func() {
+			if err := os.RemoveAll(tmpDir); rErr == nil && err != nil {
+				rErr = errors.Wrapf(err, "failed to remove temporary directory")
+			}
+		}()
+		baseDir = tmpDir
+	}
+
+	commit, checkoutDir, err := p.checkout(baseDir)
+	if err != nil {
+		return err
+	}
+	return fn(checkoutDir, commit)
+}
+
+// checkout ensures that the mirror in the provided base directory is up to date and that the ref of the provider is
+// checked out, and returns the commit of the ref and its checkout directory. The mirror is shared by every provider and
+// process that uses the same cache, so it is only cloned, fetched and read while holding a lock on the base directory.
+// Checkout directories are never modified once they exist, so they can be read without the lock.
+func (p *gitIRProvider) checkout(baseDir string) (string, string, error) {
+	// the repository may be private, so its clone is only readable by the current user
+	if err := os.MkdirAll(baseDir, 0700); err != nil {
+		return "", "", errors.WithStack(err)
+	}
+	unlock, err := conjureircli.LockFile(filepath.Join(baseDir, "repo.lock"))
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to lock the cache of Git repository %s", gitDisplayURL(p.repoURL))
+	}
+	defer unlock()
+
+	mirrorDir := filepath.Join(baseDir, "repo.git")
+	if err := p.ensureMirror(mirrorDir); err != nil {
+		return "", "", err
+	}
+	commit, err := p.resolveCommit(mirrorDir)
+	if err != nil {
+		return "", "", err
+	}
+	checkoutDir := filepath.Join(baseDir, "checkouts", commit)
+	if err := ensureGitCheckout(mirrorDir, commit, checkoutDir); err != nil {
+		return "", "", errors.Wrapf(err, "failed to check out %s of Git repository %s", p.ref, gitDisplayURL(p.repoURL))
+	}
+	return commit, checkoutDir, nil
+}
+
+// ensureMirror creates a mirror clone of the repository in the provided directory if it does not already exist.
+func (p *gitIRProvider) ensureMirror(mirrorDir string) error {
+	if _, err := os.Stat(mirrorDir); err == nil {
+		return nil
+	}
+	if p.offline {
+		return errors.Errorf("Git repository %s is not in the cache and cannot be cloned in offline mode", gitDisplayURL(p.repoURL))
+	}
+	if err := os.MkdirAll(filepath.Dir(mirrorDir), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	tmpDir, err := ioutil.TempDir(filepath.Dir(mirrorDir), ".repo-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+	if _, err := runGit("", "clone", "--quiet", "--mirror", p.repoURL, tmpDir); err != nil {
+		return errors.Wrapf(err, "failed to clone Git repository %s", gitDisplayURL(p.repoURL))
+	}
+	if err := os.Rename(tmpDir, mirrorDir); err != nil {
+		if _, statErr := os.Stat(mirrorDir); statErr == nil {
+			// another process created the mirror concurrently
+			return nil
+		}
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// resolveCommit returns the commit of the ref of the provider. Refs that are not full commit hashes may move, so the
+// mirror is fetched before they are resolved unless the provider is offline.
+func (p *gitIRProvider) resolveCommit(mirrorDir string) (string, error) {
+	refArg := p.ref + "^{commit}"
+	if gitCommitRegexp.MatchString(p.ref) {
+		if commit, err := runGit(mirrorDir, "rev-parse", "--verify", "--quiet", refArg); err == nil {
+			return commit, nil
+		}
+	}
+	if !p.offline {
+		if _, err := runGit(mirrorDir, "fetch", "--quiet", "--prune", "--tags", "--force", "origin"); err != nil {
+			return "", errors.Wrapf(err, "failed to fetch Git repository %s", gitDisplayURL(p.repoURL))
+		}
+	}
+	commit, err := runGit(mirrorDir, "rev-parse", "--verify", "--quiet", refArg)
+	if err != nil {
+		return "", errors.Errorf("ref %s does not exist in Git repository %s", p.ref, gitDisplayURL(p.repoURL))
+	}
+	return commit, nil
+}
+
+// ensureGitCheckout writes the tree of the provided commit to the provided checkout directory if it does not already
+// exist. The tree is written to a temporary directory that is renamed to the checkout directory so that a partially
+// written checkout is never observed.
+func ensureGitCheckout(mirrorDir, commit, checkoutDir string) error {
+	if _, err := os.Stat(checkoutDir); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(checkoutDir), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	tmpDir, err := ioutil.TempDir(filepath.Dir(checkoutDir), "."+commit+"-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	cmd := exec.Command("git", "--git-dir", mirrorDir, "archive", "--format=tar", commit)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := cmd.Start(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := extractTar(stdout, tmpDir); err != nil {
+		_ = cmd.Wait()
+		return err
+	}
+	if err := cmd.Wait(); err != nil {
+		return errors.Wrapf(err, "git archive failed with output %s", stderr.String())
+	}
+
+	if err := os.Rename(tmpDir, checkoutDir); err != nil {
+		if _, statErr := os.Stat(checkoutDir); statErr == nil {
+			// another process created the checkout concurrently
+			return nil
+		}
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// extractTar writes the directories and regular files in the provided tar stream to the provided directory.
+func extractTar(r io.Reader, dstDir string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.Wrapf(err, "failed to read tar stream")
+		}
+		dst := filepath.Join(dstDir, filepath.FromSlash(hdr.Name))
+		if !strings.HasPrefix(dst, filepath.Clean(dstDir)+string(filepath.Separator)) {
+			return errors.Errorf("tar entry %s is outside of the destination directory", hdr.Name)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(dst, 0755); err != nil {
+				return errors.WithStack(err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+				return errors.WithStack(err)
+			}
+			f, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				_ = f.Close()
+				return errors.WithStack(err)
+			}
+			if err := f.Close(); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+}
+
+// runGit runs git with the provided arguments and returns its trimmed standard output. If gitDir is non-empty, it is
+// used as the Git directory. Errors include the output of the command but not its arguments, which may contain the
+// URL of the repository.
+func runGit(gitDir string, args ...string) (string, error) {
+	subcommand := args[0]
+	if gitDir != "" {
+		args = append([]string{"--git-dir", gitDir}, args...)
+	}
+	cmd := exec.Command("git", args...)
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return "", errors.Wrapf(err, "git %s failed with output %s", subcommand, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// gitDisplayURL returns the provided repository URL with any password removed. Unlike redactURL, it returns the input
+// unmodified if it is not a URL with a scheme, since scp-like locations and paths cannot contain passwords.
+func gitDisplayURL(repoURL string) string {
+	if parsedURL, err := url.Parse(repoURL); err == nil && parsedURL.Scheme != "" {
+		return parsedURL.Redacted()
+	}
+	return repoURL
+}
@@ -0,0 +1,202 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/palantir/godel/v2/framework/builtintasks/installupdate/layout"
+	"github.com/palantir/pkg/safejson"
+	"github.com/pkg/errors"
+)
+
+// IRCache is an on-disk cache of content fetched from remote sources. Content is stored by its SHA-256 digest, and an
+// index maps the URL of every cached source to the digest of its content along with the validators (ETag and
+// Last-Modified) returned by the server so that subsequent requests can be made conditionally. The cache also stores IR
+// compiled from YAML, keyed by the digest of the inputs of the compilation.
+//
+// Content that was fetched with credentials is keyed by the identity of the credentials as well as the URL, so it is
+// never returned for requests with other credentials or without credentials, and it is stored in a separate directory
+// that only the current user can read. Compiled IR may be compiled from private sources, so it is only readable by the
+// current user as well.
+type IRCache struct {
+	dir string
+}
+
+// NewIRCache returns a cache that stores its content in the provided directory.
+func NewIRCache(dir string) *IRCache {
+	return &IRCache{
+		dir: dir,
+	}
+}
+
+// DefaultIRCacheDir returns the default directory for the cache, which is "conjure-plugin" in the cache directory of
+// the gödel home directory.
+func DefaultIRCacheDir() (string, error) {
+	godelHomeDir, err := layout.GodelHomePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(godelHomeDir, layout.CacheDir, "conjure-plugin"), nil
+}
+
+type irCacheEntry struct {
+	URL string `json:"url"`
+	// AuthIdentity identifies the credentials with which the content was fetched without including any secrets. Empty
+	// if the content was fetched without credentials.
+	AuthIdentity string `json:"authIdentity,omitempty"`
+	Digest       string `json:"digest"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// lookup returns the cache entry and content for the provided URL fetched with the credentials with the provided
+// identity. Returns false if the URL is not in the cache for the identity or if the cached content does not match its
+// recorded digest.
+func (c *IRCache) lookup(rawURL, authIdentity string) (irCacheEntry, []byte, bool) {
+	indexBytes, err := ioutil.ReadFile(c.indexPath(rawURL, authIdentity))
+	if err != nil {
+		return irCacheEntry{}, nil, false
+	}
+	var entry irCacheEntry
+	if err := safejson.Unmarshal(indexBytes, &entry); err != nil || entry.URL != redactURL(rawURL) || entry.AuthIdentity != authIdentity {
+		return irCacheEntry{}, nil, false
+	}
+	content, err := ioutil.ReadFile(c.blobPath(entry.Digest, authIdentity))
+	if err != nil || sha256Digest(content) != entry.Digest {
+		return irCacheEntry{}, nil, false
+	}
+	return entry, content, true
+}
+
+// store writes the provided content to the cache and records it as the content for the URL and the credentials of the
+// provided entry. The digest of the entry is set by this function. The index is keyed by the full URL, but only the
+// redacted form of the URL is written to disk.
+func (c *IRCache) store(entry irCacheEntry, content []byte) error {
+	indexPath := c.indexPath(entry.URL, entry.AuthIdentity)
+	entry.URL = redactURL(entry.URL)
+	entry.Digest = sha256Digest(content)
+	dirPerm, filePerm := cachePerms(entry.AuthIdentity != "")
+	if err := writeFileAtomicWithPerms(c.blobPath(entry.Digest, entry.AuthIdentity), content, dirPerm, filePerm); err != nil {
+		return errors.Wrapf(err, "failed to write content to cache")
+	}
+	indexBytes, err := safejson.Marshal(entry)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := writeFileAtomicWithPerms(indexPath, indexBytes, dirPerm, filePerm); err != nil {
+		return errors.Wrapf(err, "failed to write cache index")
+	}
+	return nil
+}
+
+// lookupCompiled returns the IR compiled from YAML that is stored for the provided key. Returns false if the key is not
+// in the cache.
+func (c *IRCache) lookupCompiled(key string) ([]byte, bool) {
+	irBytes, err := ioutil.ReadFile(c.compiledPath(key))
+	if err != nil {
+		return nil, false
+	}
+	return irBytes, true
+}
+
+// storeCompiled writes the provided IR compiled from YAML to the cache for the provided key.
+func (c *IRCache) storeCompiled(key string, irBytes []byte) error {
+	dirPerm, filePerm := cachePerms(true)
+	if err := writeFileAtomicWithPerms(c.compiledPath(key), irBytes, dirPerm, filePerm); err != nil {
+		return errors.Wrapf(err, "failed to write compiled IR to cache")
+	}
+	return nil
+}
+
+// ClearCompiled removes all of the IR compiled from YAML from the cache. Content fetched from remote sources is kept.
+func (c *IRCache) ClearCompiled() error {
+	return errors.Wrapf(os.RemoveAll(filepath.Join(c.dir, "compiled")), "failed to clear compiled IR from cache")
+}
+
+// Clear removes all of the content of the cache.
+func (c *IRCache) Clear() error {
+	return errors.Wrapf(os.RemoveAll(c.dir), "failed to clear cache")
+}
+
+func (c *IRCache) compiledPath(key string) string {
+	return filepath.Join(c.dir, "compiled", key+".json")
+}
+
+func (c *IRCache) indexPath(rawURL, authIdentity string) string {
+	if authIdentity == "" {
+		return filepath.Join(c.dir, "index", sha256Digest([]byte(rawURL))+".json")
+	}
+	return filepath.Join(c.dir, "private", "index", sha256Digest([]byte(rawURL+"\x00"+authIdentity))+".json")
+}
+
+func (c *IRCache) blobPath(digest, authIdentity string) string {
+	if authIdentity == "" {
+		return filepath.Join(c.dir, "blobs", "sha256", digest)
+	}
+	return filepath.Join(c.dir, "private", "blobs", "sha256", digest)
+}
+
+// perms returns the permissions of the directories and files of content that is private if the provided value is true
+// and that is public otherwise.
+func cachePerms(private bool) (os.FileMode, os.FileMode) {
+	if private {
+		return 0700, 0600
+	}
+	return 0755, 0644
+}
+
+func sha256Digest(content []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(content))
+}
+
+// writeFileAtomic writes the provided content to a temporary file in the destination directory and renames it to the
+// destination so that concurrent readers never observe partially written content.
+func writeFileAtomic(dst string, content []byte) error {
+	return writeFileAtomicWithPerms(dst, content, 0755, 0644)
+}
+
+// writeFileAtomicWithPerms writes the provided content in the same manner as writeFileAtomic. Directories that do not
+// exist are created with the provided directory permissions and the file is written with the provided file
+// permissions.
+func writeFileAtomicWithPerms(dst string, content []byte, dirPerm, filePerm os.FileMode) (rErr error) {
+	if err := os.MkdirAll(filepath.Dir(dst), dirPerm); err != nil {
+		return errors.WithStack(err)
+	}
+	tmpFile, err := ioutil.TempFile(filepath.Dir(dst), "."+filepath.Base(dst)+"-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer func() {
+		if rErr != nil {
+			_ = os.Remove(tmpFile.Name())
+		}
+	}()
+	if _, err := tmpFile.Write(content); err != nil {
+		_ = tmpFile.Close()
+		return errors.WithStack(err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.Chmod(tmpFile.Name(), filePerm); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Rename(tmpFile.Name(), dst))
+}
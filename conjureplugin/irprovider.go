@@ -15,11 +15,16 @@
 package conjureplugin
 
 import (
+	"crypto/sha256"
+	"fmt"
 	"io/ioutil"
-	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin/yamlcompiler"
 	"github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli"
-	"github.com/palantir/pkg/safehttp"
 	"github.com/pkg/errors"
 )
 
@@ -29,58 +34,218 @@ type IRProvider interface {
 	GeneratedFromYAML() bool
 }
 
+// LocatorResolver is implemented by IRProviders that can describe the concrete source of the IR that they provide.
+type LocatorResolver interface {
+	// ResolvedLocator returns a string that identifies the concrete source of the IR provided by the provider. Any
+	// dynamic portion of the locator (such as a "latest" version) is resolved, and credentials are never included.
+	ResolvedLocator() (string, error)
+}
+
+// InputDigester is implemented by IRProviders that generate IR from local inputs.
+type InputDigester interface {
+	// InputDigest returns the hex-encoded SHA-256 digest of the inputs from which the IR is generated.
+	InputDigest() (string, error)
+}
+
 var _ IRProvider = &localYAMLIRProvider{}
+var _ LocatorResolver = &localYAMLIRProvider{}
+var _ InputDigester = &localYAMLIRProvider{}
+
+// YAMLCompiler identifies the compiler that generates IR from Conjure YAML.
+type YAMLCompiler string
+
+const (
+	// YAMLCompilerJava generates IR using the bundled Conjure CLI, which requires a Java runtime. It is the default.
+	YAMLCompilerJava = YAMLCompiler("java")
+	// YAMLCompilerNative generates IR using the Go implementation of the Conjure compiler in the yamlcompiler package.
+	YAMLCompilerNative = YAMLCompiler("native")
+)
+
+// yamlConfig is the configuration of the providers that generate IR from Conjure YAML.
+type yamlConfig struct {
+	compiler   YAMLCompiler
+	cliParams  []conjureircli.Param
+	cache      *IRCache
+	extensions map[string]interface{}
+}
+
+func newYAMLConfig(params ...YAMLParam) yamlConfig {
+	var cfg yamlConfig
+	for _, param := range params {
+		if param == nil {
+			continue
+		}
+		param.apply(&cfg)
+	}
+	return cfg
+}
+
+type YAMLParam interface {
+	apply(*yamlConfig)
+}
+
+type yamlParamFn func(*yamlConfig)
+
+func (fn yamlParamFn) apply(cfg *yamlConfig) {
+	fn(cfg)
+}
+
+// YAMLCompilerParam returns a parameter that configures a provider to generate IR from Conjure YAML using the provided
+// compiler. If the compiler is empty, YAMLCompilerJava is used.
+func YAMLCompilerParam(compiler YAMLCompiler) YAMLParam {
+	return yamlParamFn(func(cfg *yamlConfig) {
+		cfg.compiler = compiler
+	})
+}
+
+// YAMLCLIParams returns a parameter that configures a provider that generates IR using YAMLCompilerJava to run the
+// Conjure CLI with the provided parameters.
+func YAMLCLIParams(params ...conjureircli.Param) YAMLParam {
+	return yamlParamFn(func(cfg *yamlConfig) {
+		cfg.cliParams = append(cfg.cliParams, params...)
+	})
+}
+
+// YAMLCacheParam returns a parameter that configures a provider that generates IR using YAMLCompilerJava to store the
+// IR that it compiles in the provided cache. The IR is keyed by the digest of the YAML files, the version of the
+// Conjure CLI and the extensions, and IR for inputs that have not changed is read from the cache instead of running the
+// CLI.
+func YAMLCacheParam(cache *IRCache) YAMLParam {
+	return yamlParamFn(func(cfg *yamlConfig) {
+		cfg.cache = cache
+	})
+}
+
+// YAMLExtensionsParam returns a parameter that configures a provider to set the extensions of the IR that it generates
+// from YAML to the provided extensions.
+func YAMLExtensionsParam(extensions map[string]interface{}) YAMLParam {
+	return yamlParamFn(func(cfg *yamlConfig) {
+		cfg.extensions = extensions
+	})
+}
 
 type localYAMLIRProvider struct {
 	path string
+	yamlConfig
 }
 
 // NewLocalYAMLIRProvider returns an IRProvider that provides IR generated from local YAML. The provided path must be a
 // path to a Conjure YAML file or a directory that contains Conjure YAML files.
-func NewLocalYAMLIRProvider(path string) IRProvider {
+func NewLocalYAMLIRProvider(path string, params ...YAMLParam) IRProvider {
 	return &localYAMLIRProvider{
-		path: path,
+		path:       path,
+		yamlConfig: newYAMLConfig(params...),
 	}
 }
 
 func (p *localYAMLIRProvider) IRBytes() ([]byte, error) {
-	return conjureircli.InputPathToIR(p.path)
+	switch p.compiler {
+	case "", YAMLCompilerJava:
+		return p.cachedCLIIRBytes()
+	case YAMLCompilerNative:
+		extensionsParam, err := yamlcompiler.ExtensionsParam(p.extensions)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid extensions")
+		}
+		return yamlcompiler.InputPathToIRWithParams(p.path, extensionsParam)
+	default:
+		return nil, errors.Errorf("unknown YAML compiler %q", p.compiler)
+	}
+}
+
+// cachedCLIIRBytes returns the IR generated by the Conjure CLI. If the provider has a cache, the IR is read from the
+// cache if it contains IR for the current inputs and is stored in the cache otherwise.
+func (p *localYAMLIRProvider) cachedCLIIRBytes() ([]byte, error) {
+	cliParams, err := p.allCLIParams()
+	if err != nil {
+		return nil, err
+	}
+	if p.cache == nil {
+		return conjureircli.InputPathToIRWithParams(p.path, cliParams...)
+	}
+	compilationKey, err := conjureircli.CompilationKey(cliParams...)
+	if err != nil {
+		return nil, err
+	}
+	key, err := p.compiledCacheKey(compilationKey)
+	if err != nil {
+		// the input files cannot be determined if the YAML is invalid, in which case the CLI reports the error
+		return conjureircli.InputPathToIRWithParams(p.path, cliParams...)
+	}
+	if irBytes, ok := p.cache.lookupCompiled(key); ok {
+		return irBytes, nil
+	}
+	irBytes, err := conjureircli.InputPathToIRWithParams(p.path, cliParams...)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.cache.storeCompiled(key, irBytes); err != nil {
+		return nil, err
+	}
+	return irBytes, nil
+}
+
+// allCLIParams returns the parameters with which the Conjure CLI is run, including the extensions.
+func (p *localYAMLIRProvider) allCLIParams() ([]conjureircli.Param, error) {
+	extensionsParam, err := conjureircli.ExtensionsParam(p.extensions)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid extensions")
+	}
+	return append(append([]conjureircli.Param(nil), p.cliParams...), extensionsParam), nil
+}
+
+// compiledCacheKey returns the key of the IR compiled from the current inputs of the provider by the Conjure CLI with
+// the provided compilation key.
+func (p *localYAMLIRProvider) compiledCacheKey(compilationKey string) (string, error) {
+	inputDigest, err := yamlInputDigest(p.path)
+	if err != nil {
+		return "", err
+	}
+	return sha256Digest([]byte(inputDigest + "\x00" + compilationKey)), nil
 }
 
 func (p *localYAMLIRProvider) GeneratedFromYAML() bool {
 	return true
 }
 
+func (p *localYAMLIRProvider) ResolvedLocator() (string, error) {
+	return p.path, nil
+}
+
+func (p *localYAMLIRProvider) InputDigest() (string, error) {
+	return yamlInputDigest(p.path)
+}
+
 var _ IRProvider = &urlIRProvider{}
+var _ LocatorResolver = &urlIRProvider{}
 
 type urlIRProvider struct {
 	irURL string
+	remoteConfig
 }
 
 // NewHTTPIRProvider returns an IRProvider that that provides IR downloaded from the provided URL over HTTP.
-func NewHTTPIRProvider(irURL string) IRProvider {
+func NewHTTPIRProvider(irURL string, params ...RemoteParam) IRProvider {
 	return &urlIRProvider{
-		irURL: irURL,
+		irURL:        irURL,
+		remoteConfig: newRemoteConfig(params...),
 	}
 }
 
 func (p *urlIRProvider) IRBytes() ([]byte, error) {
-	resp, cleanup, err := safehttp.Get(http.DefaultClient, p.irURL)
-	if err != nil {
-		return nil, errors.WithStack(err)
-	}
-	defer cleanup()
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.Errorf("expected response status 200 when fetching IR from remote source %s, but got %d", p.irURL, resp.StatusCode)
-	}
-	return ioutil.ReadAll(resp.Body)
+	return p.fetch(p.irURL)
 }
 
 func (p *urlIRProvider) GeneratedFromYAML() bool {
 	return false
 }
 
+func (p *urlIRProvider) ResolvedLocator() (string, error) {
+	return redactURL(p.irURL), nil
+}
+
 var _ IRProvider = &localFileIRProvider{}
+var _ LocatorResolver = &localFileIRProvider{}
 
 type localFileIRProvider struct {
 	path string
@@ -100,3 +265,110 @@ func (p *localFileIRProvider) IRBytes() ([]byte, error) {
 func (p *localFileIRProvider) GeneratedFromYAML() bool {
 	return false
 }
+
+func (p *localFileIRProvider) ResolvedLocator() (string, error) {
+	return p.path, nil
+}
+
+var _ IRProvider = &pinnedIRProvider{}
+var _ LocatorResolver = &pinnedIRProvider{}
+var _ InputDigester = &pinnedIRProvider{}
+
+type pinnedIRProvider struct {
+	provider IRProvider
+	sha256   string
+}
+
+// NewPinnedIRProvider returns an IRProvider that provides the IR provided by the given provider and returns an error if
+// the hex-encoded SHA-256 digest of that IR does not match the provided digest.
+func NewPinnedIRProvider(provider IRProvider, sha256Digest string) IRProvider {
+	return &pinnedIRProvider{
+		provider: provider,
+		sha256:   strings.ToLower(sha256Digest),
+	}
+}
+
+func (p *pinnedIRProvider) IRBytes() ([]byte, error) {
+	irBytes, err := p.provider.IRBytes()
+	if err != nil {
+		return nil, err
+	}
+	if actual := sha256Digest(irBytes); actual != p.sha256 {
+		return nil, errors.Errorf("SHA-256 digest of IR does not match pinned digest\nExpected: %s\nActual:   %s", p.sha256, actual)
+	}
+	return irBytes, nil
+}
+
+func (p *pinnedIRProvider) GeneratedFromYAML() bool {
+	return p.provider.GeneratedFromYAML()
+}
+
+func (p *pinnedIRProvider) ResolvedLocator() (string, error) {
+	return resolvedLocator(p.provider)
+}
+
+func (p *pinnedIRProvider) InputDigest() (string, error) {
+	return inputDigest(p.provider)
+}
+
+// resolvedLocator returns the resolved locator of the provided provider if it implements LocatorResolver and an empty
+// string otherwise.
+func resolvedLocator(provider IRProvider) (string, error) {
+	if resolver, ok := provider.(LocatorResolver); ok {
+		return resolver.ResolvedLocator()
+	}
+	return "", nil
+}
+
+// inputDigest returns the input digest of the provided provider if it implements InputDigester and an empty string
+// otherwise.
+func inputDigest(provider IRProvider) (string, error) {
+	if digester, ok := provider.(InputDigester); ok {
+		return digester.InputDigest()
+	}
+	return "", nil
+}
+
+// yamlInputDigest returns the hex-encoded SHA-256 digest of the Conjure YAML files that are compiled for the provided
+// path, which is either a single file or a directory: the files that the compiler reads for the path and every file
+// that they import, including imported files outside of the path. The digest covers the path of every file relative to
+// the path (or to its directory if it is a file) and its content.
+func yamlInputDigest(inPath string) (string, error) {
+	fi, err := os.Stat(inPath)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	rootDir := inPath
+	if !fi.IsDir() {
+		rootDir = filepath.Dir(inPath)
+	}
+	files, err := yamlcompiler.InputPathFiles(inPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to determine the input files of %s", inPath)
+	}
+	var relPaths []string
+	for _, file := range files {
+		relPath, err := filepath.Rel(rootDir, file)
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		relPaths = append(relPaths, filepath.ToSlash(relPath))
+	}
+	sort.Strings(relPaths)
+
+	h := sha256.New()
+	for _, relPath := range relPaths {
+		content, err := ioutil.ReadFile(filepath.Join(rootDir, filepath.FromSlash(relPath)))
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		_, _ = fmt.Fprintf(h, "%s\x00%d\x00", relPath, len(content))
+		_, _ = h.Write(content)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func isYAMLFile(filePath string) bool {
+	lowercasePath := strings.ToLower(filePath)
+	return strings.HasSuffix(lowercasePath, ".yml") || strings.HasSuffix(lowercasePath, ".yaml")
+}
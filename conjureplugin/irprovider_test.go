@@ -0,0 +1,590 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testIRJSON = `{"version":1,"errors":[],"types":[],"services":[],"extensions":{}}`
+
+func TestHTTPIRProviderAuth(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, hasBasicAuth := r.BasicAuth()
+		switch {
+		case r.Header.Get("Authorization") == "Bearer test-token",
+			hasBasicAuth && username == "test-user" && password == "test-password",
+			r.Header.Get("X-Api-Key") == "test-api-key":
+			_, _ = fmt.Fprint(w, testIRJSON)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer ts.Close()
+
+	tsURL, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	netrcPath := filepath.Join(t.TempDir(), ".netrc")
+	require.NoError(t, ioutil.WriteFile(netrcPath, []byte(fmt.Sprintf("machine other.host login other password other\nmachine %s\n  login test-user\n  password test-password\n", tsURL.Hostname())), 0600))
+
+	for i, tc := range []struct {
+		name string
+		auth conjureplugin.RemoteAuth
+	}{
+		{
+			name: "bearer token",
+			auth: conjureplugin.RemoteAuth{BearerToken: "test-token"},
+		},
+		{
+			name: "basic auth",
+			auth: conjureplugin.RemoteAuth{Username: "test-user", Password: "test-password"},
+		},
+		{
+			name: "headers",
+			auth: conjureplugin.RemoteAuth{Headers: map[string]string{"X-Api-Key": "test-api-key"}},
+		},
+		{
+			name: "netrc",
+			auth: conjureplugin.RemoteAuth{NetrcPath: netrcPath},
+		},
+	} {
+		got, err := conjureplugin.NewHTTPIRProvider(ts.URL+"/ir.json", conjureplugin.RemoteAuthParam(tc.auth)).IRBytes()
+		require.NoError(t, err, "Case %d: %s", i, tc.name)
+		assert.Equal(t, testIRJSON, string(got), "Case %d: %s", i, tc.name)
+	}
+
+	_, err = conjureplugin.NewHTTPIRProvider(ts.URL + "/ir.json").IRBytes()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "but got 401")
+
+	_, err = conjureplugin.NewHTTPIRProvider(ts.URL+"/ir.json", conjureplugin.RemoteAuthParam(conjureplugin.RemoteAuth{
+		BearerToken: "wrong-secret-token",
+	})).IRBytes()
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "wrong-secret-token")
+
+	_, err = conjureplugin.NewHTTPIRProvider("http://user:url-secret@" + tsURL.Host + "/ir.json").IRBytes()
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "url-secret")
+}
+
+func TestMavenIRProvider(t *testing.T) {
+	repoDir := t.TempDir()
+	for _, version := range []string{"1.0.0", "1.1.0", "2.0.0-rc1"} {
+		versionDir := filepath.Join(repoDir, "com", "palantir", "spec", "test-api", version)
+		require.NoError(t, os.MkdirAll(versionDir, 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(versionDir, "test-api-"+version+".conjure.json"), []byte(`{"version":"`+version+`"}`), 0644))
+	}
+	require.NoError(t, ioutil.WriteFile(filepath.Join(repoDir, "com", "palantir", "spec", "test-api", "maven-metadata.xml"), []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<metadata>
+  <groupId>com.palantir.spec</groupId>
+  <artifactId>test-api</artifactId>
+  <versioning>
+    <latest>2.0.0-rc1</latest>
+    <release>1.1.0</release>
+    <versions>
+      <version>1.0.0</version>
+      <version>1.1.0</version>
+      <version>2.0.0-rc1</version>
+    </versions>
+  </versioning>
+</metadata>
+`), 0644))
+
+	ts := httptest.NewServer(http.StripPrefix("/artifactory/conjure-release", http.FileServer(http.Dir(repoDir))))
+	defer ts.Close()
+
+	for i, tc := range []struct {
+		coordinate string
+		repository string
+		want       string
+	}{
+		{"com.palantir.spec:test-api:1.0.0", "file://" + repoDir, "1.0.0"},
+		{"com.palantir.spec:test-api:latest", repoDir, "2.0.0-rc1"},
+		{"com.palantir.spec:test-api:release", "file://" + repoDir + "/", "1.1.0"},
+		{"com.palantir.spec:test-api:1.0.0", ts.URL + "/artifactory/conjure-release", "1.0.0"},
+		{"com.palantir.spec:test-api:release", ts.URL + "/artifactory/conjure-release/", "1.1.0"},
+	} {
+		provider, err := conjureplugin.NewMavenIRProvider(tc.coordinate, tc.repository)
+		require.NoError(t, err, "Case %d", i)
+		got, err := provider.IRBytes()
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, `{"version":"`+tc.want+`"}`, string(got), "Case %d", i)
+		assert.False(t, provider.GeneratedFromYAML(), "Case %d", i)
+	}
+
+	_, err := conjureplugin.NewMavenIRProvider("com.palantir.spec:test-api", repoDir)
+	assert.EqualError(t, err, `Maven coordinate "com.palantir.spec:test-api" must be of the form group:artifact:version`)
+
+	provider, err := conjureplugin.NewMavenIRProvider("com.palantir.spec:test-api:3.0.0", repoDir)
+	require.NoError(t, err)
+	_, err = provider.IRBytes()
+	assert.EqualError(t, err, "file com/palantir/spec/test-api/3.0.0/test-api-3.0.0.conjure.json does not exist in Maven repository "+repoDir)
+}
+
+func TestHTTPIRProviderCache(t *testing.T) {
+	var requests, notModifiedResponses int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModifiedResponses++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = fmt.Fprint(w, testIRJSON)
+	}))
+	cache := conjureplugin.NewIRCache(t.TempDir())
+	irURL := ts.URL + "/ir.json"
+
+	_, err := conjureplugin.NewHTTPIRProvider(irURL, conjureplugin.RemoteCacheParam(cache), conjureplugin.RemoteOfflineParam(true)).IRBytes()
+	assert.EqualError(t, err, "remote source "+irURL+" is not in the cache and cannot be fetched in offline mode")
+	assert.Equal(t, 0, requests)
+
+	for i := 0; i < 2; i++ {
+		got, err := conjureplugin.NewHTTPIRProvider(irURL, conjureplugin.RemoteCacheParam(cache)).IRBytes()
+		require.NoError(t, err)
+		assert.Equal(t, testIRJSON, string(got))
+	}
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, 1, notModifiedResponses)
+
+	ts.Close()
+	got, err := conjureplugin.NewHTTPIRProvider(irURL, conjureplugin.RemoteCacheParam(cache), conjureplugin.RemoteOfflineParam(true)).IRBytes()
+	require.NoError(t, err)
+	assert.Equal(t, testIRJSON, string(got))
+	assert.Equal(t, 2, requests)
+}
+
+func TestHTTPIRProviderCacheAuthenticated(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprint(w, testIRJSON)
+	}))
+	defer ts.Close()
+	cacheDir := t.TempDir()
+	cache := conjureplugin.NewIRCache(cacheDir)
+	irURL := ts.URL + "/ir.json"
+	authParam := conjureplugin.RemoteAuthFuncParam("token-env=TEST_TOKEN", func() (conjureplugin.RemoteAuth, error) {
+		return conjureplugin.RemoteAuth{BearerToken: "test-token"}, nil
+	})
+
+	got, err := conjureplugin.NewHTTPIRProvider(irURL, conjureplugin.RemoteCacheParam(cache), authParam).IRBytes()
+	require.NoError(t, err)
+	assert.Equal(t, testIRJSON, string(got))
+
+	// content fetched with credentials is only readable by the current user
+	privateDir := filepath.Join(cacheDir, "private")
+	fi, err := os.Stat(privateDir)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), fi.Mode().Perm())
+	require.NoError(t, filepath.Walk(privateDir, func(path string, info os.FileInfo, err error) error {
+		require.NoError(t, err)
+		if info.Mode().IsRegular() {
+			assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), path)
+		}
+		return nil
+	}))
+	assert.NoDirExists(t, filepath.Join(cacheDir, "blobs"))
+
+	// content fetched with credentials is not returned for other credentials or without credentials
+	for _, params := range [][]conjureplugin.RemoteParam{
+		nil,
+		{conjureplugin.RemoteAuthFuncParam("token-env=OTHER_TOKEN", func() (conjureplugin.RemoteAuth, error) {
+			return conjureplugin.RemoteAuth{BearerToken: "test-token"}, nil
+		})},
+	} {
+		_, err := conjureplugin.NewHTTPIRProvider(irURL, append(params, conjureplugin.RemoteCacheParam(cache), conjureplugin.RemoteOfflineParam(true))...).IRBytes()
+		assert.EqualError(t, err, "remote source "+irURL+" is not in the cache and cannot be fetched in offline mode")
+	}
+	got, err = conjureplugin.NewHTTPIRProvider(irURL, conjureplugin.RemoteCacheParam(cache), authParam, conjureplugin.RemoteOfflineParam(true)).IRBytes()
+	require.NoError(t, err)
+	assert.Equal(t, testIRJSON, string(got))
+}
+
+func TestPinnedIRProvider(t *testing.T) {
+	irFile := filepath.Join(t.TempDir(), "ir.json")
+	require.NoError(t, ioutil.WriteFile(irFile, []byte(testIRJSON), 0644))
+	digest := fmt.Sprintf("%x", sha256.Sum256([]byte(testIRJSON)))
+
+	got, err := conjureplugin.NewPinnedIRProvider(conjureplugin.NewLocalFileIRProvider(irFile), strings.ToUpper(digest)).IRBytes()
+	require.NoError(t, err)
+	assert.Equal(t, testIRJSON, string(got))
+
+	wrongDigest := strings.Repeat("0", 64)
+	_, err = conjureplugin.NewPinnedIRProvider(conjureplugin.NewLocalFileIRProvider(irFile), wrongDigest).IRBytes()
+	assert.EqualError(t, err, "SHA-256 digest of IR does not match pinned digest\nExpected: "+wrongDigest+"\nActual:   "+digest)
+}
+
+func TestGitIRProvider(t *testing.T) {
+	workDir := t.TempDir()
+	runGit := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = workDir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, "git %v failed with output %s", args, string(output))
+		return strings.TrimSpace(string(output))
+	}
+	runGit("init", "--quiet")
+	require.NoError(t, os.MkdirAll(filepath.Join(workDir, "ir"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(workDir, "ir", "api.conjure.json"), []byte(testIRJSON), 0644))
+	runGit("add", ".")
+	runGit("commit", "--quiet", "-m", "Add IR")
+	runGit("tag", "v1.0.0")
+	commit := runGit("rev-parse", "HEAD")
+	repoDir := filepath.Join(t.TempDir(), "repo.git")
+	runGit("clone", "--quiet", "--bare", workDir, repoDir)
+
+	cache := conjureplugin.NewIRCache(t.TempDir())
+	for i, ref := range []string{"v1.0.0", commit} {
+		provider, err := conjureplugin.NewGitIRProvider(repoDir, ref, "ir/api.conjure.json", nil, conjureplugin.RemoteCacheParam(cache))
+		require.NoError(t, err, "Case %d", i)
+		got, err := provider.IRBytes()
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, testIRJSON, string(got), "Case %d", i)
+		assert.False(t, provider.GeneratedFromYAML(), "Case %d", i)
+
+		locator, err := provider.(conjureplugin.LocatorResolver).ResolvedLocator()
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, repoDir+"@"+commit+":ir/api.conjure.json", locator, "Case %d", i)
+	}
+
+	// cached checkouts can be used in offline mode
+	provider, err := conjureplugin.NewGitIRProvider(repoDir, "v1.0.0", "ir/api.conjure.json", nil, conjureplugin.RemoteCacheParam(cache), conjureplugin.RemoteOfflineParam(true))
+	require.NoError(t, err)
+	got, err := provider.IRBytes()
+	require.NoError(t, err)
+	assert.Equal(t, testIRJSON, string(got))
+
+	// a branch is resolved once, so the resolved locator is the commit from which the IR was read even if the branch
+	// moves afterwards
+	runGit("push", "--quiet", repoDir, "HEAD:refs/heads/release")
+	provider, err = conjureplugin.NewGitIRProvider(repoDir, "release", "ir/api.conjure.json", nil)
+	require.NoError(t, err)
+	got, err = provider.IRBytes()
+	require.NoError(t, err)
+	assert.Equal(t, testIRJSON, string(got))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(workDir, "ir", "api.conjure.json"), []byte("{}"), 0644))
+	runGit("commit", "--quiet", "-a", "-m", "Update IR")
+	runGit("push", "--quiet", repoDir, "HEAD:refs/heads/release")
+	locator, err := provider.(conjureplugin.LocatorResolver).ResolvedLocator()
+	require.NoError(t, err)
+	assert.Equal(t, repoDir+"@"+commit+":ir/api.conjure.json", locator)
+
+	provider, err = conjureplugin.NewGitIRProvider(repoDir, "v2.0.0", "ir/api.conjure.json", nil)
+	require.NoError(t, err)
+	_, err = provider.IRBytes()
+	assert.EqualError(t, err, "ref v2.0.0 does not exist in Git repository "+repoDir)
+
+	provider, err = conjureplugin.NewGitIRProvider(repoDir, "v1.0.0", "ir/missing.conjure.json", nil)
+	require.NoError(t, err)
+	_, err = provider.IRBytes()
+	assert.EqualError(t, err, "path ir/missing.conjure.json does not exist in Git repository "+repoDir+" at v1.0.0")
+
+	_, err = conjureplugin.NewGitIRProvider(repoDir, "v1.0.0", "../ir.json", nil)
+	assert.EqualError(t, err, "path ../ir.json must be a relative path within the repository")
+	_, err = conjureplugin.NewGitIRProvider(repoDir, "v1.0.0", "ir/../../ir.json", nil)
+	assert.EqualError(t, err, "path ir/../../ir.json must be a relative path within the repository")
+	_, err = conjureplugin.NewGitIRProvider(repoDir, "v1.0.0", "/ir.json", nil)
+	assert.EqualError(t, err, "path /ir.json must be a relative path within the repository")
+	_, err = conjureplugin.NewGitIRProvider(repoDir, "v1.0.0", "..schemas/api.yml", nil)
+	assert.NoError(t, err)
+}
+
+func TestMergedIRProvider(t *testing.T) {
+	const (
+		fooType    = `{"type":"object","object":{"typeName":{"name":"Foo","package":"com.palantir.foo"},"fields":[],"docs":null}}`
+		barType    = `{"type":"alias","alias":{"typeName":{"name":"Bar","package":"com.palantir.bar"},"alias":{"type":"primitive","primitive":"STRING"},"docs":null}}`
+		barIntType = `{"type":"alias","alias":{"typeName":{"name":"Bar","package":"com.palantir.bar"},"alias":{"type":"primitive","primitive":"INTEGER"}}}`
+	)
+	dir := t.TempDir()
+	writeIR := func(name string, types ...string) conjureplugin.IRProvider {
+		irFile := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(irFile, []byte(`{"version":1,"errors":[],"types":[`+strings.Join(types, ",")+`],"services":[],"extensions":{}}`), 0644))
+		return conjureplugin.NewLocalFileIRProvider(irFile)
+	}
+	fooBar := writeIR("foo-bar.json", fooType, barType)
+	bar := writeIR("bar.json", barType)
+	barInt := writeIR("bar-int.json", barIntType)
+
+	got, err := conjureplugin.NewMergedIRProvider(bar, fooBar).IRBytes()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"version":1,"errors":[],"types":[`+barType+`,`+fooType+`],"services":[],"extensions":{}}`, string(got))
+
+	locator, err := conjureplugin.NewMergedIRProvider(bar, fooBar).(conjureplugin.LocatorResolver).ResolvedLocator()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "bar.json")+", "+filepath.Join(dir, "foo-bar.json"), locator)
+
+	_, err = conjureplugin.NewMergedIRProvider(fooBar, barInt).IRBytes()
+	assert.EqualError(t, err, "type com.palantir.bar.Bar is defined differently by source 0 and source 1")
+}
+
+func TestArchiveIRProvider(t *testing.T) {
+	entries := map[string]string{
+		"META-INF/MANIFEST.MF":                 "Manifest-Version: 1.0\n",
+		"conjure/api-1.0.0.conjure.json":       testIRJSON,
+		"conjure/other/other-1.0.0.conjure.ir": "{}",
+	}
+	var entryNames []string
+	for k := range entries {
+		entryNames = append(entryNames, k)
+	}
+	sort.Strings(entryNames)
+
+	zipBuf := &bytes.Buffer{}
+	zipWriter := zip.NewWriter(zipBuf)
+	for _, name := range entryNames {
+		w, err := zipWriter.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(entries[name]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zipWriter.Close())
+
+	tgzBuf := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(tgzBuf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for _, name := range entryNames {
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(entries[name])), Typeflag: tar.TypeReg}))
+		_, err := tarWriter.Write([]byte(entries[name]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+
+	dir := t.TempDir()
+	jarPath := filepath.Join(dir, "api-1.0.0.jar")
+	require.NoError(t, ioutil.WriteFile(jarPath, zipBuf.Bytes(), 0644))
+	var tgzRequests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&tgzRequests, 1)
+		_, _ = w.Write(tgzBuf.Bytes())
+	}))
+	defer ts.Close()
+	tgzURL := ts.URL + "/api-1.0.0.tgz"
+
+	for i, tc := range []struct {
+		archive     string
+		entry       string
+		wantLocator string
+	}{
+		{jarPath, "", jarPath + "!conjure/api-1.0.0.conjure.json"},
+		{"file://" + jarPath, "conjure/api-*.conjure.json", "file://" + jarPath + "!conjure/api-1.0.0.conjure.json"},
+		{tgzURL, "conjure/api-1.0.0.conjure.json", tgzURL + "!conjure/api-1.0.0.conjure.json"},
+	} {
+		provider, err := conjureplugin.NewArchiveIRProvider(tc.archive, tc.entry)
+		require.NoError(t, err, "Case %d", i)
+		got, err := provider.IRBytes()
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, testIRJSON, string(got), "Case %d", i)
+		assert.False(t, provider.GeneratedFromYAML(), "Case %d", i)
+
+		locator, err := provider.(conjureplugin.LocatorResolver).ResolvedLocator()
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.wantLocator, locator, "Case %d", i)
+	}
+	// the IR and the resolved locator are read from a single download of the archive
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tgzRequests))
+
+	provider, err := conjureplugin.NewArchiveIRProvider(jarPath, "conjure/api-2.*")
+	require.NoError(t, err)
+	_, err = provider.IRBytes()
+	assert.EqualError(t, err, "archive "+jarPath+" does not contain an entry that matches conjure/api-2.*")
+
+	provider, err = conjureplugin.NewArchiveIRProvider(jarPath, "*/*")
+	require.NoError(t, err)
+	_, err = provider.IRBytes()
+	assert.EqualError(t, err, "archive "+jarPath+" contains multiple entries that match */*: META-INF/MANIFEST.MF, conjure/api-1.0.0.conjure.json")
+
+	_, err = conjureplugin.NewArchiveIRProvider(filepath.Join(dir, "api.rar"), "")
+	assert.EqualError(t, err, "archive "+filepath.Join(dir, "api.rar")+" must have one of the extensions .zip, .jar, .tgz, .tar.gz or .tar")
+}
+
+func TestCommandIRProvider(t *testing.T) {
+	dir := t.TempDir()
+	for i, tc := range []struct {
+		command conjureplugin.IRCommand
+		want    string
+		wantErr string
+	}{
+		{
+			command: conjureplugin.IRCommand{Executable: "sh", Args: []string{"-c", "printf '%s' '" + testIRJSON + "'"}},
+			want:    testIRJSON,
+		},
+		{
+			command: conjureplugin.IRCommand{
+				Executable: "sh",
+				Args:       []string{"-c", "mkdir -p out && printf '%s' '" + testIRJSON + "' > out/ir.json"},
+				Dir:        dir,
+				OutputFile: "out/ir.json",
+			},
+			want: testIRJSON,
+		},
+		{
+			// the output file written by the previous case is not read as the output of this command
+			command: conjureplugin.IRCommand{
+				Executable: "true",
+				Dir:        dir,
+				OutputFile: "out/ir.json",
+			},
+			wantErr: "command true did not write its output file " + filepath.Join(dir, "out", "ir.json"),
+		},
+		{
+			command: conjureplugin.IRCommand{Executable: "sh", Args: []string{"-c", "echo 'invalid input' >&2; exit 3"}},
+			wantErr: "command sh failed with stderr:\ninvalid input: exit status 3",
+		},
+		{
+			command: conjureplugin.IRCommand{Executable: "sh", Args: []string{"-c", "echo 'starting' >&2; sleep 10"}, Timeout: 100 * time.Millisecond},
+			wantErr: "command sh timed out after 100ms with stderr:\nstarting",
+		},
+	} {
+		provider, err := conjureplugin.NewCommandIRProvider(tc.command)
+		require.NoError(t, err, "Case %d", i)
+		assert.False(t, provider.GeneratedFromYAML(), "Case %d", i)
+		got, err := provider.IRBytes()
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, string(got), "Case %d", i)
+	}
+}
+
+func TestLocalYAMLIRProviderCache(t *testing.T) {
+	dir := t.TempDir()
+	// the fake CLI records every compilation and writes the number of compilations so far as the IR
+	countFile := filepath.Join(dir, "count")
+	cliPath := filepath.Join(dir, "conjure")
+	require.NoError(t, ioutil.WriteFile(cliPath, []byte(`#!/bin/sh
+if [ "$1" = "--version" ]; then
+  echo "4.40.0"
+  exit 0
+fi
+for last; do true; done
+echo compiled >> "`+countFile+`"
+wc -l < "`+countFile+`" | tr -d ' \n' > "$last"
+`), 0755))
+	yamlDir := filepath.Join(dir, "yaml")
+	require.NoError(t, os.MkdirAll(yamlDir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(yamlDir, "api.yml"), []byte("types: {}\n"), 0644))
+
+	cache := conjureplugin.NewIRCache(filepath.Join(dir, "cache"))
+	provider := conjureplugin.NewLocalYAMLIRProvider(yamlDir,
+		conjureplugin.YAMLCLIParams(conjureircli.CLIPathParam(cliPath)),
+		conjureplugin.YAMLCacheParam(cache),
+	)
+	for _, want := range []string{"1", "1"} {
+		got, err := provider.IRBytes()
+		require.NoError(t, err)
+		assert.Equal(t, want, string(got))
+	}
+
+	// changing the input compiles the YAML again
+	require.NoError(t, ioutil.WriteFile(filepath.Join(yamlDir, "api.yml"), []byte("types: {definitions: {}}\n"), 0644))
+	got, err := provider.IRBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "2", string(got))
+
+	// changing a file that is imported from outside of the locator path compiles the YAML again
+	commonDir := filepath.Join(dir, "common")
+	require.NoError(t, os.MkdirAll(commonDir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(commonDir, "common.yml"), []byte("types: {}\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(yamlDir, "api.yml"), []byte("types:\n  conjure-imports:\n    common: ../common/common.yml\n"), 0644))
+	got, err = provider.IRBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "3", string(got))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(commonDir, "common.yml"), []byte("types: {definitions: {}}\n"), 0644))
+	got, err = provider.IRBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "4", string(got))
+
+	// files that the compiler does not read are not part of the input
+	require.NoError(t, ioutil.WriteFile(filepath.Join(yamlDir, "notes.yaml"), []byte("notes: {}\n"), 0644))
+	got, err = provider.IRBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "4", string(got))
+
+	// changing the extensions compiles the YAML again
+	extensionsParam, err := conjureircli.ExtensionsParam(map[string]interface{}{"foo": "bar"})
+	require.NoError(t, err)
+	got, err = conjureplugin.NewLocalYAMLIRProvider(yamlDir,
+		conjureplugin.YAMLCLIParams(conjureircli.CLIPathParam(cliPath), extensionsParam),
+		conjureplugin.YAMLCacheParam(cache),
+	).IRBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "5", string(got))
+
+	// replacing the CLI at the same path compiles the YAML again
+	cliBytes, err := ioutil.ReadFile(cliPath)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(cliPath, append(cliBytes, "# upgraded\n"...), 0755))
+	got, err = provider.IRBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "6", string(got))
+
+	require.NoError(t, cache.ClearCompiled())
+	got, err = provider.IRBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "7", string(got))
+}
+
+func TestLocalYAMLIRProviderNativeExtensions(t *testing.T) {
+	yamlPath := filepath.Join(t.TempDir(), "api.yml")
+	require.NoError(t, ioutil.WriteFile(yamlPath, []byte("types: {}\n"), 0644))
+
+	provider := conjureplugin.NewLocalYAMLIRProvider(yamlPath,
+		conjureplugin.YAMLCompilerParam(conjureplugin.YAMLCompilerNative),
+		conjureplugin.YAMLExtensionsParam(map[string]interface{}{
+			"product-version": "1.2.3",
+		}),
+	)
+	got, err := provider.IRBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "version" : 1,
+  "errors" : [ ],
+  "types" : [ ],
+  "services" : [ ],
+  "extensions" : {
+    "product-version" : "1.2.3"
+  }
+}`, string(got))
+}
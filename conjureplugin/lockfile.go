@@ -0,0 +1,192 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// LockfileName is the name of the lockfile that records the resolved IR sources of a configuration. It is written to
+// the same directory as the configuration file.
+const LockfileName = "conjure-plugin.lock"
+
+const lockfileHeader = "# This file is generated by the conjure task of godel-conjure-plugin and should not be manually edited.\n"
+
+// Lockfile records the IR resolved for every project of a configuration.
+type Lockfile struct {
+	Version  int                      `yaml:"version"`
+	Projects map[string]LockedProject `yaml:"projects"`
+}
+
+// LockedProject records the IR resolved for a single project.
+type LockedProject struct {
+	// Locator identifies the concrete source of the IR.
+	Locator string `yaml:"locator"`
+	// IRSHA256 is the hex-encoded SHA-256 digest of the IR without its extensions (see irDigest).
+	IRSHA256 string `yaml:"ir-sha256"`
+	// InputSHA256 is the hex-encoded SHA-256 digest of the inputs from which the IR was generated. Only set for IR
+	// generated from YAML.
+	InputSHA256 string `yaml:"input-sha256,omitempty"`
+}
+
+// Lock resolves the IR for the provided parameters and compares it to the lockfile at the provided path. If update is
+// true, the lockfile is written with the resolved values. Otherwise, the stale entries are printed and an error is
+// returned if the lockfile does not match.
+func Lock(params ConjureProjectParams, lockfilePath string, update bool, stdout io.Writer) error {
+	current, err := ComputeLockfile(params)
+	if err != nil {
+		return err
+	}
+	if update {
+		return WriteLockfile(lockfilePath, current)
+	}
+	existing, exists, err := ReadLockfile(lockfilePath)
+	if err != nil {
+		return err
+	}
+	staleEntries := []string{fmt.Sprintf("%s does not exist", LockfileName)}
+	if exists {
+		staleEntries = existing.StaleEntries(current)
+	}
+	if len(staleEntries) == 0 {
+		return nil
+	}
+	printStaleLockEntries(stdout, staleEntries)
+	return errors.Errorf("%s is out of date: run with --update to update it", LockfileName)
+}
+
+// ComputeLockfile returns the lockfile for the provided parameters. The IR for every project is resolved.
+func ComputeLockfile(params ConjureProjectParams) (Lockfile, error) {
+	lockfile := Lockfile{
+		Version:  1,
+		Projects: make(map[string]LockedProject),
+	}
+	for i, param := range params.OrderedParams() {
+		key := params.SortedKeys[i]
+		irBytes, err := param.IRProvider.IRBytes()
+		if err != nil {
+			return Lockfile{}, errors.Wrapf(err, "failed to get IR for %s", key)
+		}
+		lockedProject, err := newLockedProject(param.IRProvider, irBytes)
+		if err != nil {
+			return Lockfile{}, errors.Wrapf(err, "failed to compute lock for %s", key)
+		}
+		lockfile.Projects[key] = lockedProject
+	}
+	return lockfile, nil
+}
+
+// newLockedProject returns the lock entry for the provided provider, which provided the provided IR.
+func newLockedProject(provider IRProvider, irBytes []byte) (LockedProject, error) {
+	locator, err := resolvedLocator(provider)
+	if err != nil {
+		return LockedProject{}, err
+	}
+	inputSHA256, err := inputDigest(provider)
+	if err != nil {
+		return LockedProject{}, err
+	}
+	return LockedProject{
+		Locator:     locator,
+		IRSHA256:    irDigest(irBytes),
+		InputSHA256: inputSHA256,
+	}, nil
+}
+
+// irDigest returns the hex-encoded SHA-256 digest of the provided IR with its top-level "extensions" removed. The
+// extensions do not affect the generated code and can contain values that change with every commit (such as
+// "${git.commit}"), so they are excluded from the digests that are recorded in the lockfile and in the generation
+// state. If the IR is not a JSON object, the digest of the IR as provided is returned.
+func irDigest(irBytes []byte) string {
+	var ir map[string]json.RawMessage
+	if err := json.Unmarshal(irBytes, &ir); err != nil {
+		return sha256Digest(irBytes)
+	}
+	if _, ok := ir["extensions"]; !ok {
+		return sha256Digest(irBytes)
+	}
+	delete(ir, "extensions")
+	strippedIRBytes, err := json.Marshal(ir)
+	if err != nil {
+		return sha256Digest(irBytes)
+	}
+	return sha256Digest(strippedIRBytes)
+}
+
+// ReadLockfile reads the lockfile at the provided path. Returns false if the file does not exist.
+func ReadLockfile(lockfilePath string) (Lockfile, bool, error) {
+	lockfileBytes, err := ioutil.ReadFile(lockfilePath)
+	if os.IsNotExist(err) {
+		return Lockfile{}, false, nil
+	} else if err != nil {
+		return Lockfile{}, false, errors.WithStack(err)
+	}
+	var lockfile Lockfile
+	if err := yaml.UnmarshalStrict(lockfileBytes, &lockfile); err != nil {
+		return Lockfile{}, false, errors.Wrapf(err, "failed to unmarshal lockfile %s", lockfilePath)
+	}
+	return lockfile, true, nil
+}
+
+// WriteLockfile writes the provided lockfile to the provided path.
+func WriteLockfile(lockfilePath string, lockfile Lockfile) error {
+	lockfileBytes, err := yaml.Marshal(lockfile)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(ioutil.WriteFile(lockfilePath, append([]byte(lockfileHeader), lockfileBytes...), 0644))
+}
+
+// StaleEntries returns a description of every project whose entry in the provided current lockfile differs from its
+// entry in this lockfile. The descriptions are sorted by project key. Returns an empty slice if the lockfiles match.
+func (l Lockfile) StaleEntries(current Lockfile) []string {
+	var keys []string
+	for k := range current.Projects {
+		keys = append(keys, k)
+	}
+	for k := range l.Projects {
+		if _, ok := current.Projects[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var stale []string
+	for _, k := range keys {
+		locked, inLock := l.Projects[k]
+		curr, inCurrent := current.Projects[k]
+		switch {
+		case !inLock:
+			stale = append(stale, fmt.Sprintf("%s: not in lockfile", k))
+		case !inCurrent:
+			stale = append(stale, fmt.Sprintf("%s: in lockfile but not in configuration", k))
+		case locked.Locator != curr.Locator:
+			stale = append(stale, fmt.Sprintf("%s: locator changed from %s to %s", k, locked.Locator, curr.Locator))
+		case locked.InputSHA256 != curr.InputSHA256:
+			stale = append(stale, fmt.Sprintf("%s: input digest changed from %s to %s", k, locked.InputSHA256, curr.InputSHA256))
+		case locked.IRSHA256 != curr.IRSHA256:
+			stale = append(stale, fmt.Sprintf("%s: IR digest changed from %s to %s", k, locked.IRSHA256, curr.IRSHA256))
+		}
+	}
+	return stale
+}
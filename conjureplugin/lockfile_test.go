@@ -0,0 +1,82 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLock(t *testing.T) {
+	tmpDir := t.TempDir()
+	irFile := filepath.Join(tmpDir, "ir.json")
+	require.NoError(t, ioutil.WriteFile(irFile, []byte(testIRJSON), 0644))
+	lockfilePath := filepath.Join(tmpDir, conjureplugin.LockfileName)
+
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"project-1"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"project-1": {
+				OutputDir:  "conjure",
+				IRProvider: conjureplugin.NewLocalFileIRProvider(irFile),
+			},
+		},
+	}
+
+	outputBuf := &bytes.Buffer{}
+	err := conjureplugin.Lock(params, lockfilePath, false, outputBuf)
+	assert.EqualError(t, err, "conjure-plugin.lock is out of date: run with --update to update it")
+	assert.Equal(t, "conjure-plugin.lock is out of date:\n  conjure-plugin.lock does not exist\n", outputBuf.String())
+
+	require.NoError(t, conjureplugin.Lock(params, lockfilePath, true, outputBuf))
+	lockfile, exists, err := conjureplugin.ReadLockfile(lockfilePath)
+	require.NoError(t, err)
+	require.True(t, exists)
+	// the digest is computed over the IR without its extensions
+	oldDigest := fmt.Sprintf("%x", sha256.Sum256([]byte(`{"errors":[],"services":[],"types":[],"version":1}`)))
+	assert.Equal(t, conjureplugin.Lockfile{
+		Version: 1,
+		Projects: map[string]conjureplugin.LockedProject{
+			"project-1": {
+				Locator:  irFile,
+				IRSHA256: oldDigest,
+			},
+		},
+	}, lockfile)
+	require.NoError(t, conjureplugin.Lock(params, lockfilePath, false, outputBuf))
+
+	// the extensions of the IR are not part of the digest
+	irWithExtensions := `{"version":1,"types":[],"errors":[],"services":[],"extensions":{"commit":"abc123"}}`
+	require.NoError(t, ioutil.WriteFile(irFile, []byte(irWithExtensions), 0644))
+	require.NoError(t, conjureplugin.Lock(params, lockfilePath, true, outputBuf))
+	irWithExtensions = `{"version":1,"types":[],"errors":[],"services":[],"extensions":{"commit":"def456"}}`
+	require.NoError(t, ioutil.WriteFile(irFile, []byte(irWithExtensions), 0644))
+	require.NoError(t, conjureplugin.Lock(params, lockfilePath, false, outputBuf))
+
+	updatedIR := `{"version":1}`
+	require.NoError(t, ioutil.WriteFile(irFile, []byte(updatedIR), 0644))
+	outputBuf = &bytes.Buffer{}
+	err = conjureplugin.Lock(params, lockfilePath, false, outputBuf)
+	require.Error(t, err)
+	assert.Equal(t, fmt.Sprintf("conjure-plugin.lock is out of date:\n  project-1: IR digest changed from %s to %x\n", oldDigest, sha256.Sum256([]byte(updatedIR))), outputBuf.String())
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/palantir/distgo/distgo"
+	"github.com/palantir/distgo/publisher"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MavenVersionLatest is the version that resolves to the latest version of an artifact in a Maven repository.
+	MavenVersionLatest = "latest"
+	// MavenVersionRelease is the version that resolves to the latest release version of an artifact in a Maven
+	// repository.
+	MavenVersionRelease = "release"
+)
+
+var _ IRProvider = &mavenIRProvider{}
+var _ LocatorResolver = &mavenIRProvider{}
+
+type mavenIRProvider struct {
+	groupID    string
+	artifactID string
+	version    string
+	repository string
+	remoteConfig
+
+	// mu guards the resolved version, which is resolved once so that the IR and the resolved locator always use the same
+	// version.
+	mu              sync.Mutex
+	resolvedVersion string
+}
+
+// NewMavenIRProvider returns an IRProvider that provides IR resolved from a Maven-layout repository. The coordinate
+// must be of the form "group:artifact:version", where the version may be "latest" or "release" to resolve the version
+// using the "maven-metadata.xml" file of the artifact. The repository is the base URL of the repository: it can be an
+// HTTP(S) URL, a "file://" URL or a local path. The IR is expected to be published as
+// "{{group-path}}/{{artifact}}/{{version}}/{{artifact}}-{{version}}.conjure.json", which matches the layout used by the
+// "publish" task.
+func NewMavenIRProvider(coordinate, repository string, params ...RemoteParam) (IRProvider, error) {
+	parts := strings.Split(coordinate, ":")
+	if len(parts) != 3 {
+		return nil, errors.Errorf("Maven coordinate %q must be of the form group:artifact:version", coordinate)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return nil, errors.Errorf("Maven coordinate %q must be of the form group:artifact:version", coordinate)
+		}
+	}
+	if repository == "" {
+		return nil, errors.Errorf("repository must be specified for Maven coordinate %s", coordinate)
+	}
+	return &mavenIRProvider{
+		groupID:      parts[0],
+		artifactID:   parts[1],
+		version:      parts[2],
+		repository:   strings.TrimSuffix(repository, "/"),
+		remoteConfig: newRemoteConfig(params...),
+	}, nil
+}
+
+func (p *mavenIRProvider) IRBytes() ([]byte, error) {
+	irPath, err := p.irPath()
+	if err != nil {
+		return nil, err
+	}
+	return p.readRepositoryFile(irPath)
+}
+
+func (p *mavenIRProvider) GeneratedFromYAML() bool {
+	return false
+}
+
+func (p *mavenIRProvider) ResolvedLocator() (string, error) {
+	irPath, err := p.irPath()
+	if err != nil {
+		return "", err
+	}
+	return redactURL(p.repository + "/" + irPath), nil
+}
+
+// irPath returns the path of the IR file relative to the root of the repository.
+func (p *mavenIRProvider) irPath() (string, error) {
+	version, err := p.resolveVersion()
+	if err != nil {
+		return "", err
+	}
+	productPath := publisher.MavenProductPath(distgo.ProductTaskOutputInfo{
+		Project: distgo.ProjectInfo{
+			Version: version,
+		},
+		Product: distgo.ProductOutputInfo{
+			ID: distgo.ProductID(p.artifactID),
+		},
+	}, p.groupID)
+	return path.Join(productPath, irFileName(p.artifactID, version)), nil
+}
+
+type mavenMetadata struct {
+	Versioning struct {
+		Latest   string   `xml:"latest"`
+		Release  string   `xml:"release"`
+		Versions []string `xml:"versions>version"`
+	} `xml:"versioning"`
+}
+
+// resolveVersion returns the concrete version of the artifact. If the version of the provider is "latest" or
+// "release", the version is determined using the metadata file of the artifact.
+func (p *mavenIRProvider) resolveVersion() (string, error) {
+	if p.version != MavenVersionLatest && p.version != MavenVersionRelease {
+		return p.version, nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.resolvedVersion == "" {
+		version, err := p.readMetadataVersion()
+		if err != nil {
+			return "", err
+		}
+		p.resolvedVersion = version
+	}
+	return p.resolvedVersion, nil
+}
+
+// readMetadataVersion returns the version specified by the Maven metadata of the artifact for the "latest" or
+// "release" version of the provider.
+func (p *mavenIRProvider) readMetadataVersion() (string, error) {
+	artifactPath := path.Join(strings.Replace(p.groupID, ".", "/", -1), p.artifactID)
+	metadataBytes, err := p.readRepositoryFile(path.Join(artifactPath, "maven-metadata.xml"))
+	if err != nil && p.isLocal() {
+		// local repositories populated by Maven write "maven-metadata-local.xml" rather than "maven-metadata.xml"
+		metadataBytes, err = p.readRepositoryFile(path.Join(artifactPath, "maven-metadata-local.xml"))
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read Maven metadata for %s:%s", p.groupID, p.artifactID)
+	}
+
+	var metadata mavenMetadata
+	if err := xml.Unmarshal(metadataBytes, &metadata); err != nil {
+		return "", errors.Wrapf(err, "failed to parse Maven metadata for %s:%s", p.groupID, p.artifactID)
+	}
+	version := metadata.Versioning.Release
+	if p.version == MavenVersionLatest && metadata.Versioning.Latest != "" {
+		version = metadata.Versioning.Latest
+	}
+	if version == "" && len(metadata.Versioning.Versions) > 0 {
+		version = metadata.Versioning.Versions[len(metadata.Versioning.Versions)-1]
+	}
+	if version == "" {
+		return "", errors.Errorf("Maven metadata for %s:%s does not specify a %s version", p.groupID, p.artifactID, p.version)
+	}
+	return version, nil
+}
+
+func (p *mavenIRProvider) isLocal() bool {
+	_, ok := p.localRepositoryDir()
+	return ok
+}
+
+// localRepositoryDir returns the local directory of the repository if the repository is a "file://" URL or a path.
+func (p *mavenIRProvider) localRepositoryDir() (string, bool) {
+	parsedURL, err := url.Parse(p.repository)
+	if err != nil || parsedURL.Scheme == "" {
+		return p.repository, true
+	}
+	if parsedURL.Scheme == "file" {
+		return parsedURL.Path, true
+	}
+	return "", false
+}
+
+// readRepositoryFile returns the content of the file at the provided slash-separated path relative to the root of the
+// repository.
+func (p *mavenIRProvider) readRepositoryFile(relPath string) ([]byte, error) {
+	if repoDir, ok := p.localRepositoryDir(); ok {
+		filePath := filepath.Join(repoDir, filepath.FromSlash(relPath))
+		fileBytes, err := ioutil.ReadFile(filePath)
+		if os.IsNotExist(err) {
+			return nil, errors.Errorf("file %s does not exist in Maven repository %s", relPath, repoDir)
+		} else if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return fileBytes, nil
+	}
+	return p.fetch(p.repository + "/" + relPath)
+}
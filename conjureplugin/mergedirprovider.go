@@ -0,0 +1,243 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	conjurego "github.com/palantir/conjure-go/v6/conjure"
+	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
+	"github.com/pkg/errors"
+)
+
+var _ IRProvider = &mergedIRProvider{}
+var _ LocatorResolver = &mergedIRProvider{}
+var _ InputDigester = &mergedIRProvider{}
+
+type mergedIRProvider struct {
+	providers []IRProvider
+}
+
+// NewMergedIRProvider returns an IRProvider that provides the IR that results from merging the IR provided by all of
+// the provided providers into a single Conjure definition. Definitions with the same name that are provided by multiple
+// providers are included once if they are identical, and it is an error for them to differ.
+func NewMergedIRProvider(providers ...IRProvider) IRProvider {
+	return &mergedIRProvider{
+		providers: providers,
+	}
+}
+
+func (p *mergedIRProvider) IRBytes() ([]byte, error) {
+	var defs []spec.ConjureDefinition
+	for i, provider := range p.providers {
+		irBytes, err := provider.IRBytes()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get IR for source %d", i)
+		}
+		def, err := conjurego.FromIRBytes(irBytes)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse IR for source %d", i)
+		}
+		defs = append(defs, def)
+	}
+	merged, err := MergeConjureDefinitions(defs...)
+	if err != nil {
+		return nil, err
+	}
+	irBytes, err := json.Marshal(merged)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal merged IR")
+	}
+	return irBytes, nil
+}
+
+// GeneratedFromYAML returns true only if the IR of every source is generated from YAML so that IR that is not local to
+// the project is not published by default.
+func (p *mergedIRProvider) GeneratedFromYAML() bool {
+	for _, provider := range p.providers {
+		if !provider.GeneratedFromYAML() {
+			return false
+		}
+	}
+	return len(p.providers) > 0
+}
+
+// ResolvedLocator returns the resolved locators of all of the sources separated by ", ".
+func (p *mergedIRProvider) ResolvedLocator() (string, error) {
+	var locators []string
+	for _, provider := range p.providers {
+		locator, err := resolvedLocator(provider)
+		if err != nil {
+			return "", err
+		}
+		locators = append(locators, locator)
+	}
+	return strings.Join(locators, ", "), nil
+}
+
+// InputDigest returns the digest of the input digests of all of the sources. Returns an empty string if none of the
+// sources is generated from YAML.
+func (p *mergedIRProvider) InputDigest() (string, error) {
+	var digests []string
+	hasDigest := false
+	for _, provider := range p.providers {
+		digest, err := inputDigest(provider)
+		if err != nil {
+			return "", err
+		}
+		hasDigest = hasDigest || digest != ""
+		digests = append(digests, digest)
+	}
+	if !hasDigest {
+		return "", nil
+	}
+	return sha256Digest([]byte(strings.Join(digests, "\n"))), nil
+}
+
+// MergeConjureDefinitions merges the provided definitions into a single definition. Types, errors and services are
+// identified by their package and name: a definition that occurs in multiple inputs is included once if all of its
+// occurrences are identical, and an error is returned if they differ. Extensions are merged in the same manner. The
+// definitions in the result are in the order in which they first occur in the inputs.
+func MergeConjureDefinitions(defs ...spec.ConjureDefinition) (spec.ConjureDefinition, error) {
+	merged := spec.ConjureDefinition{
+		Version:    1,
+		Errors:     []spec.ErrorDefinition{},
+		Types:      []spec.TypeDefinition{},
+		Services:   []spec.ServiceDefinition{},
+		Extensions: map[string]interface{}{},
+	}
+	if len(defs) > 0 {
+		merged.Version = defs[0].Version
+	}
+
+	types := make(map[string]spec.TypeDefinition)
+	errorDefs := make(map[string]spec.ErrorDefinition)
+	services := make(map[string]spec.ServiceDefinition)
+	sources := make(map[string]int)
+	for i, def := range defs {
+		if def.Version != merged.Version {
+			return spec.ConjureDefinition{}, errors.Errorf("IR version %d of source %d does not match IR version %d of source 0", def.Version, i, merged.Version)
+		}
+		for _, typeDef := range def.Types {
+			name, err := typeDefinitionName(typeDef)
+			if err != nil {
+				return spec.ConjureDefinition{}, errors.Wrapf(err, "failed to determine name of type in source %d", i)
+			}
+			if existing, ok := types[name]; ok {
+				if err := checkIdentical("type", name, existing, typeDef, sources["type "+name], i); err != nil {
+					return spec.ConjureDefinition{}, err
+				}
+				continue
+			}
+			types[name] = typeDef
+			sources["type "+name] = i
+			merged.Types = append(merged.Types, typeDef)
+		}
+		for _, errorDef := range def.Errors {
+			name := qualifiedTypeName(errorDef.ErrorName)
+			if existing, ok := errorDefs[name]; ok {
+				if err := checkIdentical("error", name, existing, errorDef, sources["error "+name], i); err != nil {
+					return spec.ConjureDefinition{}, err
+				}
+				continue
+			}
+			errorDefs[name] = errorDef
+			sources["error "+name] = i
+			merged.Errors = append(merged.Errors, errorDef)
+		}
+		for _, serviceDef := range def.Services {
+			name := qualifiedTypeName(serviceDef.ServiceName)
+			if existing, ok := services[name]; ok {
+				if err := checkIdentical("service", name, existing, serviceDef, sources["service "+name], i); err != nil {
+					return spec.ConjureDefinition{}, err
+				}
+				continue
+			}
+			services[name] = serviceDef
+			sources["service "+name] = i
+			merged.Services = append(merged.Services, serviceDef)
+		}
+		for k, v := range def.Extensions {
+			if existing, ok := merged.Extensions[k]; ok {
+				if !reflect.DeepEqual(existing, v) {
+					return spec.ConjureDefinition{}, errors.Errorf("extension %s is defined differently by source %d and source %d", k, sources["extension "+k], i)
+				}
+				continue
+			}
+			merged.Extensions[k] = v
+			sources["extension "+k] = i
+		}
+	}
+	return merged, nil
+}
+
+// checkIdentical returns an error if the JSON representations of the provided definitions differ.
+func checkIdentical(kind, name string, existing, curr interface{}, existingSource, currSource int) error {
+	existingBytes, err := json.Marshal(existing)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s %s", kind, name)
+	}
+	currBytes, err := json.Marshal(curr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal %s %s", kind, name)
+	}
+	if string(existingBytes) != string(currBytes) {
+		return errors.Errorf("%s %s is defined differently by source %d and source %d", kind, name, existingSource, currSource)
+	}
+	return nil
+}
+
+func qualifiedTypeName(typeName spec.TypeName) string {
+	return typeName.Package + "." + typeName.Name
+}
+
+// typeDefinitionName returns the qualified name of the provided type definition.
+func typeDefinitionName(typeDef spec.TypeDefinition) (string, error) {
+	visitor := &typeNameVisitor{}
+	if err := typeDef.Accept(visitor); err != nil {
+		return "", err
+	}
+	return qualifiedTypeName(visitor.typeName), nil
+}
+
+type typeNameVisitor struct {
+	typeName spec.TypeName
+}
+
+func (v *typeNameVisitor) VisitAlias(def spec.AliasDefinition) error {
+	v.typeName = def.TypeName
+	return nil
+}
+
+func (v *typeNameVisitor) VisitEnum(def spec.EnumDefinition) error {
+	v.typeName = def.TypeName
+	return nil
+}
+
+func (v *typeNameVisitor) VisitObject(def spec.ObjectDefinition) error {
+	v.typeName = def.TypeName
+	return nil
+}
+
+func (v *typeNameVisitor) VisitUnion(def spec.UnionDefinition) error {
+	v.typeName = def.TypeName
+	return nil
+}
+
+func (v *typeNameVisitor) VisitUnknown(typeName string) error {
+	return errors.Errorf("unknown type definition type %s", typeName)
+}
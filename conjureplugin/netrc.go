@@ -0,0 +1,104 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultNetrcPath returns the path of the netrc file that should be used when none is explicitly specified. Returns
+// the value of the NETRC environment variable if it is set and ~/.netrc otherwise.
+func DefaultNetrcPath() (string, error) {
+	if netrcPath := os.Getenv("NETRC"); netrcPath != "" {
+		return netrcPath, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to determine home directory for netrc file")
+	}
+	return filepath.Join(homeDir, ".netrc"), nil
+}
+
+// netrcCredentials returns the login and password for the provided host from the netrc file at the provided path. If
+// there is no entry for the host, the "default" entry is used if one exists. Returns false if no matching entry exists
+// or if the file does not exist.
+func netrcCredentials(netrcPath, host string) (login string, password string, ok bool, rErr error) {
+	netrcBytes, err := ioutil.ReadFile(netrcPath)
+	if os.IsNotExist(err) {
+		return "", "", false, nil
+	} else if err != nil {
+		return "", "", false, errors.Wrapf(err, "failed to read netrc file %s", netrcPath)
+	}
+
+	type entry struct {
+		login    string
+		password string
+	}
+	var (
+		curr         *entry
+		hostEntry    *entry
+		defaultEntry *entry
+	)
+	lines := strings.Split(string(netrcBytes), "\n")
+	for i := 0; i < len(lines); i++ {
+		fields := strings.Fields(lines[i])
+		for j := 0; j < len(fields); j++ {
+			switch fields[j] {
+			case "machine":
+				curr = &entry{}
+				if j+1 < len(fields) && fields[j+1] == host && hostEntry == nil {
+					hostEntry = curr
+				}
+				j++
+			case "default":
+				curr = &entry{}
+				if defaultEntry == nil {
+					defaultEntry = curr
+				}
+			case "login", "password", "account":
+				if j+1 >= len(fields) || curr == nil {
+					j++
+					continue
+				}
+				switch fields[j] {
+				case "login":
+					curr.login = fields[j+1]
+				case "password":
+					curr.password = fields[j+1]
+				}
+				j++
+			case "macdef":
+				// macro definitions continue until the next blank line
+				for i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+					i++
+				}
+				j = len(fields)
+			}
+		}
+	}
+
+	if hostEntry == nil {
+		hostEntry = defaultEntry
+	}
+	if hostEntry == nil {
+		return "", "", false, nil
+	}
+	return hostEntry.login, hostEntry.password, true, nil
+}
@@ -14,6 +14,13 @@
 
 package conjureplugin
 
+import (
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
 type ConjureProjectParams struct {
 	SortedKeys []string
 	Params     map[string]ConjureProjectParam
@@ -27,6 +34,76 @@ func (p *ConjureProjectParams) OrderedParams() []ConjureProjectParam {
 	return out
 }
 
+// FilterProjects returns the parameters for the projects whose keys match at least one of the provided include
+// patterns (or all projects if there are no include patterns) and none of the provided exclude patterns. Patterns use
+// the syntax of path.Match, so "*" matches any sequence of characters other than "/". Returns an error if a pattern is
+// invalid or does not match any project.
+func (p *ConjureProjectParams) FilterProjects(include, exclude []string) (ConjureProjectParams, error) {
+	included, err := p.matchingKeys(include)
+	if err != nil {
+		return ConjureProjectParams{}, err
+	}
+	excluded, err := p.matchingKeys(exclude)
+	if err != nil {
+		return ConjureProjectParams{}, err
+	}
+	filtered := ConjureProjectParams{
+		Params: make(map[string]ConjureProjectParam),
+	}
+	for _, k := range p.SortedKeys {
+		if _, ok := excluded[k]; ok {
+			continue
+		}
+		if _, ok := included[k]; !ok && len(include) > 0 {
+			continue
+		}
+		filtered.SortedKeys = append(filtered.SortedKeys, k)
+		filtered.Params[k] = p.Params[k]
+	}
+	return filtered, nil
+}
+
+// withKeys returns the parameters of the projects whose keys are among the provided keys.
+func (p *ConjureProjectParams) withKeys(keys []string) ConjureProjectParams {
+	keySet := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		keySet[k] = struct{}{}
+	}
+	filtered := ConjureProjectParams{
+		Params: make(map[string]ConjureProjectParam),
+	}
+	for _, k := range p.SortedKeys {
+		if _, ok := keySet[k]; !ok {
+			continue
+		}
+		filtered.SortedKeys = append(filtered.SortedKeys, k)
+		filtered.Params[k] = p.Params[k]
+	}
+	return filtered
+}
+
+// matchingKeys returns the keys of the projects that match any of the provided patterns.
+func (p *ConjureProjectParams) matchingKeys(patterns []string) (map[string]struct{}, error) {
+	matching := make(map[string]struct{})
+	for _, pattern := range patterns {
+		matched := false
+		for _, k := range p.SortedKeys {
+			ok, err := path.Match(pattern, k)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid project pattern %q", pattern)
+			}
+			if ok {
+				matching[k] = struct{}{}
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, errors.Errorf("project %q does not match any project: valid projects are %s", pattern, strings.Join(p.SortedKeys, ", "))
+		}
+	}
+	return matching, nil
+}
+
 type ConjureProjectParam struct {
 	OutputDir    string
 	IRProvider   IRProvider
@@ -37,4 +114,7 @@ type ConjureProjectParam struct {
 	AcceptFuncs bool
 	// Publish specifies whether or not this Conjure project should be included in the "publish" operation.
 	Publish bool
+	// DefinitionFilter selects the types, errors and services of the IR that are generated. It does not affect the IR
+	// that is published or locked.
+	DefinitionFilter DefinitionFilter
 }
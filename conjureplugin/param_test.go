@@ -0,0 +1,78 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterProjects(t *testing.T) {
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"api-foo", "api-bar", "internal"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"api-foo":  {OutputDir: "foo"},
+			"api-bar":  {OutputDir: "bar"},
+			"internal": {OutputDir: "internal"},
+		},
+	}
+	for i, tc := range []struct {
+		include []string
+		exclude []string
+		want    []string
+		wantErr string
+	}{
+		{
+			want: []string{"api-foo", "api-bar", "internal"},
+		},
+		{
+			include: []string{"internal", "api-foo"},
+			want:    []string{"api-foo", "internal"},
+		},
+		{
+			include: []string{"api-*"},
+			exclude: []string{"*-bar"},
+			want:    []string{"api-foo"},
+		},
+		{
+			exclude: []string{"api-*"},
+			want:    []string{"internal"},
+		},
+		{
+			include: []string{"api-baz"},
+			wantErr: `project "api-baz" does not match any project: valid projects are api-foo, api-bar, internal`,
+		},
+		{
+			exclude: []string{"other-*"},
+			wantErr: `project "other-*" does not match any project: valid projects are api-foo, api-bar, internal`,
+		},
+		{
+			include: []string{"api-["},
+			wantErr: `invalid project pattern "api-[": syntax error in pattern`,
+		},
+	} {
+		got, err := params.FilterProjects(tc.include, tc.exclude)
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, got.SortedKeys, "Case %d", i)
+		assert.Len(t, got.Params, len(tc.want), "Case %d", i)
+	}
+}
@@ -62,7 +62,7 @@ func Publish(params ConjureProjectParams, projectDir string, flagVals map[distgo
 	for i, param := range paramsToPublish {
 		key := paramsToPublishKeys[i]
 		currDir := path.Join(tmpDir, fmt.Sprintf("conjure-%s", key))
-		irFileName := fmt.Sprintf("%s-%s.conjure.json", key, version)
+		irFileName := irFileName(key, version)
 		keyAsDistID := distgo.DistID(key)
 		if err := os.Mkdir(currDir, 0755); err != nil {
 			return errors.WithStack(err)
@@ -117,6 +117,11 @@ func Publish(params ConjureProjectParams, projectDir string, flagVals map[distgo
 	return nil
 }
 
+// irFileName returns the name of the file used to publish the IR for the provided product and version.
+func irFileName(productID, version string) string {
+	return fmt.Sprintf("%s-%s.conjure.json", productID, version)
+}
+
 func PublisherFlags() ([]distgo.PublisherFlag, error) {
 	return artifactory.NewArtifactoryPublisher().Flags()
 }
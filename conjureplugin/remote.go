@@ -0,0 +1,260 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/palantir/pkg/safehttp"
+	"github.com/pkg/errors"
+)
+
+// RemoteAuth specifies the credentials that are sent when fetching IR over HTTP. If BearerToken is non-empty, it is
+// sent as a bearer token. Otherwise, if Username or Password is non-empty, they are sent using basic authentication.
+// Otherwise, if NetrcPath is non-empty, the credentials for the remote host are read from the netrc file at that path.
+// Headers are always set on the request.
+//
+// The values in this struct are secrets and are never included in errors or output.
+type RemoteAuth struct {
+	BearerToken string
+	Username    string
+	Password    string
+	Headers     map[string]string
+	NetrcPath   string
+}
+
+// identity returns a description of the kinds of credentials of this value that does not include any secrets. Returns
+// an empty string if there are no credentials.
+func (a RemoteAuth) identity() string {
+	var parts []string
+	switch {
+	case a.BearerToken != "":
+		parts = append(parts, "bearer")
+	case a.Username != "" || a.Password != "":
+		parts = append(parts, "basic:"+a.Username)
+	case a.NetrcPath != "":
+		parts = append(parts, "netrc:"+a.NetrcPath)
+	}
+	var headerNames []string
+	for k := range a.Headers {
+		headerNames = append(headerNames, http.CanonicalHeaderKey(k))
+	}
+	sort.Strings(headerNames)
+	if len(headerNames) > 0 {
+		parts = append(parts, "headers:"+strings.Join(headerNames, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+func (a RemoteAuth) applyTo(req *http.Request) error {
+	switch {
+	case a.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+a.BearerToken)
+	case a.Username != "" || a.Password != "":
+		req.SetBasicAuth(a.Username, a.Password)
+	case a.NetrcPath != "":
+		login, password, ok, err := netrcCredentials(a.NetrcPath, req.URL.Hostname())
+		if err != nil {
+			return err
+		}
+		if ok {
+			req.SetBasicAuth(login, password)
+		}
+	}
+	for k, v := range a.Headers {
+		req.Header.Set(k, v)
+	}
+	return nil
+}
+
+// remoteConfig is the configuration shared by the providers that fetch content from remote sources.
+type remoteConfig struct {
+	// resolveAuth returns the credentials of remote requests. Nil if requests are not authenticated.
+	resolveAuth func() (RemoteAuth, error)
+	// authIdentity identifies the credentials returned by resolveAuth without including any secrets.
+	authIdentity string
+	cache        *IRCache
+	offline      bool
+}
+
+func newRemoteConfig(params ...RemoteParam) remoteConfig {
+	var cfg remoteConfig
+	for _, param := range params {
+		if param == nil {
+			continue
+		}
+		param.apply(&cfg)
+	}
+	return cfg
+}
+
+type RemoteParam interface {
+	apply(*remoteConfig)
+}
+
+type remoteParamFn func(*remoteConfig)
+
+func (fn remoteParamFn) apply(cfg *remoteConfig) {
+	fn(cfg)
+}
+
+// RemoteAuthParam returns a parameter that configures a provider to authenticate its remote requests using the
+// provided credentials.
+func RemoteAuthParam(auth RemoteAuth) RemoteParam {
+	return RemoteAuthFuncParam(auth.identity(), func() (RemoteAuth, error) {
+		return auth, nil
+	})
+}
+
+// RemoteAuthFuncParam returns a parameter that configures a provider to authenticate its remote requests using the
+// credentials returned by the provided function. The function is only invoked when a remote request is made, so
+// credentials that cannot be resolved do not cause an error for content that is read from the cache in offline mode
+// or for providers that are never used. The provided identity must identify the credentials without including any
+// secrets (for example, by the names of the environment variables from which they are read): content fetched with the
+// credentials is only read from the cache by providers with the same identity.
+func RemoteAuthFuncParam(identity string, resolveAuth func() (RemoteAuth, error)) RemoteParam {
+	return remoteParamFn(func(cfg *remoteConfig) {
+		cfg.resolveAuth = resolveAuth
+		cfg.authIdentity = identity
+		if cfg.authIdentity == "" {
+			cfg.authIdentity = "unspecified"
+		}
+	})
+}
+
+// RemoteCacheParam returns a parameter that configures a provider to store the content it fetches from remote sources
+// in the provided cache and to use conditional requests to revalidate cached content.
+func RemoteCacheParam(cache *IRCache) RemoteParam {
+	return remoteParamFn(func(cfg *remoteConfig) {
+		cfg.cache = cache
+	})
+}
+
+// RemoteOfflineParam returns a parameter that configures whether a provider operates in offline mode. In offline mode,
+// content is only ever read from the cache and it is an error for a remote source to not be in the cache.
+func RemoteOfflineParam(offline bool) RemoteParam {
+	return remoteParamFn(func(cfg *remoteConfig) {
+		cfg.offline = offline
+	})
+}
+
+// fetch returns the content at the provided URL using this configuration.
+func (cfg remoteConfig) fetch(rawURL string) ([]byte, error) {
+	var (
+		cachedEntry   irCacheEntry
+		cachedContent []byte
+		inCache       bool
+	)
+	if cfg.cache != nil {
+		cachedEntry, cachedContent, inCache = cfg.cache.lookup(rawURL, cfg.authIdentity)
+	}
+	if cfg.offline {
+		if !inCache {
+			return nil, errors.Errorf("remote source %s is not in the cache and cannot be fetched in offline mode", redactURL(rawURL))
+		}
+		return cachedContent, nil
+	}
+
+	var auth RemoteAuth
+	if cfg.resolveAuth != nil {
+		var err error
+		if auth, err = cfg.resolveAuth(); err != nil {
+			return nil, errors.Wrapf(err, "failed to resolve credentials for remote source %s", redactURL(rawURL))
+		}
+	}
+	resp, err := fetchRemote(rawURL, auth, cachedEntry)
+	if err != nil {
+		return nil, err
+	}
+	if resp.notModified && inCache {
+		return cachedContent, nil
+	}
+	if cfg.cache != nil {
+		if err := cfg.cache.store(irCacheEntry{
+			URL:          rawURL,
+			AuthIdentity: cfg.authIdentity,
+			ETag:         resp.etag,
+			LastModified: resp.lastModified,
+		}, resp.content); err != nil {
+			return nil, err
+		}
+	}
+	return resp.content, nil
+}
+
+type remoteResponse struct {
+	content      []byte
+	notModified  bool
+	etag         string
+	lastModified string
+}
+
+// fetchRemote performs a GET request for the provided URL using the provided credentials and returns the body of the
+// response. If the provided cache entry has validators, the request is made conditionally and the returned response
+// indicates whether the content was not modified. Returns an error if the response status is not 200 or 304.
+func fetchRemote(rawURL string, auth RemoteAuth, cachedEntry irCacheEntry) (remoteResponse, error) {
+	displayURL := redactURL(rawURL)
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return remoteResponse{}, errors.Errorf("invalid URL for remote source %s", displayURL)
+	}
+	if err := auth.applyTo(req); err != nil {
+		return remoteResponse{}, err
+	}
+	if cachedEntry.ETag != "" {
+		req.Header.Set("If-None-Match", cachedEntry.ETag)
+	}
+	if cachedEntry.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cachedEntry.LastModified)
+	}
+	resp, cleanup, err := safehttp.Do(http.DefaultClient, req)
+	if err != nil {
+		return remoteResponse{}, errors.Wrapf(err, "failed to fetch IR from remote source %s", displayURL)
+	}
+	defer cleanup()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		content, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return remoteResponse{}, errors.Wrapf(err, "failed to read IR from remote source %s", displayURL)
+		}
+		return remoteResponse{
+			content:      content,
+			etag:         resp.Header.Get("ETag"),
+			lastModified: resp.Header.Get("Last-Modified"),
+		}, nil
+	case http.StatusNotModified:
+		if cachedEntry.ETag != "" || cachedEntry.LastModified != "" {
+			return remoteResponse{
+				notModified: true,
+			}, nil
+		}
+	}
+	return remoteResponse{}, errors.Errorf("expected response status 200 when fetching IR from remote source %s, but got %d", displayURL, resp.StatusCode)
+}
+
+// redactURL returns the provided URL with any password in its user information replaced so that it is safe to include
+// in output. If the URL cannot be parsed, a placeholder is returned rather than the raw input.
+func redactURL(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return "<invalid URL>"
+	}
+	return parsedURL.Redacted()
+}
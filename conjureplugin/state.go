@@ -0,0 +1,128 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+
+	"github.com/palantir/pkg/safejson"
+	"github.com/pkg/errors"
+)
+
+// StateFileName is the name of the file that records the state of the output of every project after it was generated.
+// Run uses the state to skip projects whose output is already up to date.
+const StateFileName = "conjure-plugin-state.json"
+
+const conjureGoModulePath = "github.com/palantir/conjure-go/v6"
+
+// generationState is the content of the state file.
+type generationState struct {
+	Version  int                               `json:"version"`
+	Projects map[string]projectGenerationState `json:"projects"`
+}
+
+// projectGenerationState records the inputs of the generation of a project and the checksums of the files that it
+// generated. The output of a project is up to date if it would be generated from the same inputs and its files still
+// have the recorded checksums.
+type projectGenerationState struct {
+	IRSHA256         string `json:"irSha256"`
+	OutputDir        string `json:"outputDir"`
+	GenerateServer   bool   `json:"generateServer"`
+	AcceptFuncs      bool   `json:"acceptFuncs"`
+	DefinitionFilter string `json:"definitionFilter,omitempty"`
+	ConjureGoVersion string `json:"conjureGoVersion"`
+	// Files maps the paths of the generated files relative to the project directory to their hex-encoded SHA-256
+	// checksums.
+	Files map[string]string `json:"files"`
+}
+
+// sameInputs returns true if the provided state has the same inputs as this state. States whose conjure-go version is
+// not known never have the same inputs.
+func (s projectGenerationState) sameInputs(other projectGenerationState) bool {
+	return s.ConjureGoVersion != "" &&
+		s.IRSHA256 == other.IRSHA256 &&
+		s.OutputDir == other.OutputDir &&
+		s.GenerateServer == other.GenerateServer &&
+		s.AcceptFuncs == other.AcceptFuncs &&
+		s.DefinitionFilter == other.DefinitionFilter &&
+		s.ConjureGoVersion == other.ConjureGoVersion
+}
+
+// filesUpToDate returns true if all of the files of the state exist in the provided project directory and have the
+// recorded checksums.
+func (s projectGenerationState) filesUpToDate(projectDir string) bool {
+	if len(s.Files) == 0 {
+		return false
+	}
+	for relPath, checksum := range s.Files {
+		content, err := ioutil.ReadFile(filepath.Join(projectDir, relPath))
+		if err != nil || sha256Digest(content) != checksum {
+			return false
+		}
+	}
+	return true
+}
+
+// readGenerationState returns the state in the provided file. Returns an empty state if the file does not exist or
+// cannot be parsed, since the state only allows work to be skipped.
+func readGenerationState(stateFilePath string) generationState {
+	state := generationState{
+		Version:  1,
+		Projects: make(map[string]projectGenerationState),
+	}
+	stateBytes, err := ioutil.ReadFile(stateFilePath)
+	if err != nil {
+		return state
+	}
+	var existing generationState
+	if err := safejson.Unmarshal(stateBytes, &existing); err != nil || existing.Version != state.Version || existing.Projects == nil {
+		return state
+	}
+	return existing
+}
+
+// writeGenerationState writes the provided state to the provided file, creating its directory if necessary.
+func writeGenerationState(stateFilePath string, state generationState) error {
+	stateBytes, err := safejson.Marshal(state)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal generation state")
+	}
+	if err := os.MkdirAll(filepath.Dir(stateFilePath), 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory for generation state")
+	}
+	return writeFileAtomic(stateFilePath, stateBytes)
+}
+
+// conjureGoVersion returns the version of conjure-go that this binary was built with. Returns the empty string if it
+// cannot be determined.
+func conjureGoVersion() string {
+	buildInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, dep := range buildInfo.Deps {
+		if dep.Path != conjureGoModulePath {
+			continue
+		}
+		if dep.Replace != nil {
+			return dep.Replace.Path + "@" + dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return ""
+}
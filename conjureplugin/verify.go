@@ -15,24 +15,30 @@
 package conjureplugin
 
 import (
+	"bufio"
 	"crypto/sha256"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/palantir/conjure-go/v6/conjure"
-	"github.com/palantir/conjure-go/v6/conjure-api/conjure/spec"
 	"github.com/palantir/godel/v2/pkg/dirchecksum"
 	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
 )
 
-// diffOnDisk generates the conjure files in memory and compares checksums to on-disk files.
-func diffOnDisk(conjureDefinition spec.ConjureDefinition, projectDir string, outputConf conjure.OutputConfiguration) (dirchecksum.ChecksumsDiff, error) {
-	files, err := conjure.GenerateOutputFiles(conjureDefinition, outputConf)
-	if err != nil {
-		return dirchecksum.ChecksumsDiff{}, errors.Wrap(err, "conjure failed")
-	}
+// generatedFileHeader is the first line of the files that are generated by conjure-go.
+const generatedFileHeader = "// This file was generated by Conjure and should not be manually edited."
+
+// staleFileDiff is the description of a stale generated file in the output of verify.
+const staleFileDiff = "extra: generated file is no longer produced by Conjure"
+
+// diffOnDisk compares the checksums of the provided conjure files rendered in memory to the on-disk files.
+func diffOnDisk(files []*conjure.OutputFile, projectDir string) (dirchecksum.ChecksumsDiff, error) {
 	originalChecksums, err := checksumOnDiskFiles(files, projectDir)
 	if err != nil {
 		return dirchecksum.ChecksumsDiff{}, errors.Wrap(err, "failed to compute on-disk checksums")
@@ -106,3 +112,150 @@ func checksumOnDiskFiles(files []*conjure.OutputFile, projectDir string) (dirche
 	}
 	return set, nil
 }
+
+// renderDiffs returns a unified diff between the on-disk content and the generated content of every file in the
+// provided checksum diff. Files that do not exist on disk or are not generated are treated as empty. Each diff has the
+// provided number of lines of context and is truncated to the provided maximum number of lines if it is positive.
+// Paths that cannot be compared as files (for example, because they are directories) are described in the manner of
+// dirchecksum.ChecksumsDiff.
+func renderDiffs(diff dirchecksum.ChecksumsDiff, files []*conjure.OutputFile, context, maxLines int) (string, error) {
+	filesByRelPath := make(map[string]*conjure.OutputFile)
+	for _, file := range files {
+		relPath, err := filepath.Rel(diff.RootDir, file.AbsPath())
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		filesByRelPath[relPath] = file
+	}
+	var sortedKeys []string
+	for k := range diff.Diffs {
+		sortedKeys = append(sortedKeys, k)
+	}
+	sort.Strings(sortedKeys)
+
+	var parts []string
+	for _, k := range sortedKeys {
+		filePath := filepath.Join(diff.RootDir, k)
+		summary := fmt.Sprintf("%s: %s", filePath, diff.Diffs[k])
+
+		onDiskDate := "on disk"
+		onDisk, err := ioutil.ReadFile(filePath)
+		if os.IsNotExist(err) {
+			onDiskDate = "does not exist on disk"
+		} else if err != nil {
+			parts = append(parts, summary)
+			continue
+		}
+		generatedDate := "generated"
+		var generated []byte
+		if file, ok := filesByRelPath[k]; ok {
+			if generated, err = file.Render(); err != nil {
+				return "", err
+			}
+		} else {
+			generatedDate = "not generated"
+		}
+
+		fileDiff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        diffLines(onDisk),
+			FromFile: filePath,
+			FromDate: onDiskDate,
+			B:        diffLines(generated),
+			ToFile:   filePath,
+			ToDate:   generatedDate,
+			Context:  context,
+		})
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to compute diff for %s", filePath)
+		}
+		if fileDiff == "" {
+			parts = append(parts, summary)
+			continue
+		}
+		diffLines := strings.Split(strings.TrimSuffix(fileDiff, "\n"), "\n")
+		if maxLines > 0 && len(diffLines) > maxLines {
+			diffLines = append(diffLines[:maxLines], fmt.Sprintf("... (%d more lines)", len(diffLines)-maxLines))
+		}
+		parts = append(parts, diffLines...)
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+// diffLines returns the lines of the provided content for difflib. Empty content has no lines.
+func diffLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	return difflib.SplitLines(string(content))
+}
+
+// staleGeneratedFiles returns the sorted paths of the Go files in the provided output directory and its subdirectories
+// that were generated by conjure-go (which is determined by the header that it writes) and whose paths are not in the
+// provided set of generated paths. Vendor directories and hidden directories are not searched because the generated
+// files that they contain are not output by the plugin. Returns nil if the output directory does not exist.
+func staleGeneratedFiles(outputDir string, generatedPaths map[string]struct{}) ([]string, error) {
+	if _, err := os.Stat(outputDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+	var staleFiles []string
+	if err := filepath.Walk(outputDir, func(currPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && currPath != outputDir && (info.Name() == "vendor" || strings.HasPrefix(info.Name(), ".")) {
+			return filepath.SkipDir
+		}
+		if !info.Mode().IsRegular() || !strings.HasSuffix(info.Name(), ".go") {
+			return nil
+		}
+		if _, ok := generatedPaths[currPath]; ok {
+			return nil
+		}
+		isGenerated, err := hasGeneratedFileHeader(currPath)
+		if err != nil {
+			return err
+		}
+		if isGenerated {
+			staleFiles = append(staleFiles, currPath)
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to find stale generated files in %s", outputDir)
+	}
+	sort.Strings(staleFiles)
+	return staleFiles, nil
+}
+
+func hasGeneratedFileHeader(filePath string) (bool, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	defer func() {
+		// file is opened for reading only, so safe to ignore errors on close
+		_ = f.Close()
+	}()
+	firstLine, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, errors.WithStack(err)
+	}
+	return strings.TrimRight(firstLine, "\r\n") == generatedFileHeader, nil
+}
+
+// removeStaleGeneratedFile removes the provided stale generated file along with the directories that contain it up to
+// the provided output directory if they are empty after it is removed.
+func removeStaleGeneratedFile(outputDir, filePath string) error {
+	if err := os.Remove(filePath); err != nil {
+		return errors.Wrapf(err, "failed to remove stale generated file %s", filePath)
+	}
+	for dir := filepath.Dir(filePath); dir != outputDir && strings.HasPrefix(dir, outputDir+string(filepath.Separator)); dir = filepath.Dir(dir) {
+		entries, err := os.ReadDir(dir)
+		if err != nil || len(entries) > 0 {
+			break
+		}
+		if err := os.Remove(dir); err != nil {
+			return errors.Wrapf(err, "failed to remove empty directory %s", dir)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+
+	"github.com/palantir/godel/v2/pkg/dirchecksum"
+	"github.com/pkg/errors"
+)
+
+// VerifyFormat is the format of the report that Run prints when it verifies.
+type VerifyFormat string
+
+const (
+	// VerifyFormatText prints the differences as text for humans. This is the default.
+	VerifyFormatText VerifyFormat = "text"
+	// VerifyFormatJSON prints a JSON report of the missing, changed and extra files of every project.
+	VerifyFormatJSON VerifyFormat = "json"
+	// VerifyFormatSARIF prints the differences as a SARIF 2.1.0 log so that they can be used to annotate code.
+	VerifyFormatSARIF VerifyFormat = "sarif"
+)
+
+// ParseVerifyFormat returns the VerifyFormat with the provided name. The empty string is the text format.
+func ParseVerifyFormat(format string) (VerifyFormat, error) {
+	switch VerifyFormat(format) {
+	case "", VerifyFormatText:
+		return VerifyFormatText, nil
+	case VerifyFormatJSON, VerifyFormatSARIF:
+		return VerifyFormat(format), nil
+	default:
+		return "", errors.Errorf("invalid verify format %q: must be one of %s, %s or %s", format, VerifyFormatText, VerifyFormatJSON, VerifyFormatSARIF)
+	}
+}
+
+// VerifyReport is the report that Run prints in the JSON format when it verifies.
+type VerifyReport struct {
+	// Projects contains the report of every project keyed by the key of the project.
+	Projects map[string]ProjectVerifyReport `json:"projects"`
+	// StaleLockEntries are the descriptions of the entries of the lockfile that are out of date.
+	StaleLockEntries []string `json:"staleLockEntries"`
+}
+
+// ProjectVerifyReport contains the paths of the files of a project that differ from the output of Conjure. Paths are
+// relative to the project directory and use forward slashes.
+type ProjectVerifyReport struct {
+	// Missing are the files that would be generated but do not exist.
+	Missing []string `json:"missing"`
+	// Changed are the files whose content differs from the content that would be generated.
+	Changed []string `json:"changed"`
+	// Extra are the files that were generated previously but would no longer be generated.
+	Extra []string `json:"extra"`
+}
+
+// Failed returns true if the report contains any difference.
+func (r VerifyReport) Failed() bool {
+	for _, project := range r.Projects {
+		if len(project.Missing)+len(project.Changed)+len(project.Extra) > 0 {
+			return true
+		}
+	}
+	return len(r.StaleLockEntries) > 0
+}
+
+// newVerifyReport returns the report for the provided differences between the files on disk and the generated files
+// of every project. The differences are checksum differences from the files on disk to the generated files, so a file
+// that is only generated is "extra" in the checksum difference and missing in the report.
+func newVerifyReport(projectDiffs map[string]dirchecksum.ChecksumsDiff, staleLockEntries []string) VerifyReport {
+	report := VerifyReport{
+		Projects:         make(map[string]ProjectVerifyReport),
+		StaleLockEntries: append([]string{}, staleLockEntries...),
+	}
+	for key, diff := range projectDiffs {
+		projectReport := ProjectVerifyReport{
+			Missing: []string{},
+			Changed: []string{},
+			Extra:   []string{},
+		}
+		for relPath, desc := range diff.Diffs {
+			relPath = filepath.ToSlash(relPath)
+			switch desc {
+			case "extra":
+				projectReport.Missing = append(projectReport.Missing, relPath)
+			case "missing", staleFileDiff:
+				projectReport.Extra = append(projectReport.Extra, relPath)
+			default:
+				projectReport.Changed = append(projectReport.Changed, relPath)
+			}
+		}
+		sort.Strings(projectReport.Missing)
+		sort.Strings(projectReport.Changed)
+		sort.Strings(projectReport.Extra)
+		report.Projects[key] = projectReport
+	}
+	return report
+}
+
+// writeVerifyReport writes the provided report in the provided format, which must be JSON or SARIF, to the provided
+// writer. The provided lockfile path is the path of the lockfile relative to the project directory, which is used as
+// the location of stale lockfile entries.
+func writeVerifyReport(w io.Writer, format VerifyFormat, report VerifyReport, lockfileRelPath string) error {
+	var value interface{} = report
+	if format == VerifyFormatSARIF {
+		value = newSARIFLog(report, lockfileRelPath)
+	}
+	reportBytes, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal verify report")
+	}
+	if _, err := fmt.Fprintln(w, string(reportBytes)); err != nil {
+		return errors.Wrapf(err, "failed to write verify report")
+	}
+	return nil
+}
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	sarifRuleMissing   = "conjure-missing-file"
+	sarifRuleChanged   = "conjure-changed-file"
+	sarifRuleExtra     = "conjure-extra-file"
+	sarifRuleStaleLock = "conjure-stale-lockfile"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// newSARIFLog returns the SARIF log for the provided report. Every file that differs is a result whose location is the
+// file, and every stale lockfile entry is a result whose location is the lockfile.
+func newSARIFLog(report VerifyReport, lockfileRelPath string) sarifLog {
+	results := []sarifResult{}
+	var keys []string
+	for key := range report.Projects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		projectReport := report.Projects[key]
+		for _, category := range []struct {
+			ruleID  string
+			paths   []string
+			message string
+		}{
+			{ruleID: sarifRuleMissing, paths: projectReport.Missing, message: "Conjure project %s generates this file, but it does not exist"},
+			{ruleID: sarifRuleChanged, paths: projectReport.Changed, message: "Content differs from the output of Conjure project %s"},
+			{ruleID: sarifRuleExtra, paths: projectReport.Extra, message: "File was generated by Conjure but is no longer generated by project %s"},
+		} {
+			for _, relPath := range category.paths {
+				results = append(results, sarifResult{
+					RuleID:    category.ruleID,
+					Level:     "error",
+					Message:   sarifMessage{Text: fmt.Sprintf(category.message, key) + ": run the conjure task to update it"},
+					Locations: sarifLocations(relPath),
+				})
+			}
+		}
+	}
+	for _, entry := range report.StaleLockEntries {
+		results = append(results, sarifResult{
+			RuleID:    sarifRuleStaleLock,
+			Level:     "error",
+			Message:   sarifMessage{Text: fmt.Sprintf("%s is out of date: %s", LockfileName, entry)},
+			Locations: sarifLocations(lockfileRelPath),
+		})
+	}
+	return sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool: sarifTool{
+				Driver: sarifDriver{
+					Name:           "godel-conjure-plugin",
+					InformationURI: "https://github.com/palantir/godel-conjure-plugin",
+					Rules: []sarifRule{
+						{ID: sarifRuleMissing, ShortDescription: sarifMessage{Text: "Generated Conjure file does not exist"}},
+						{ID: sarifRuleChanged, ShortDescription: sarifMessage{Text: "Generated Conjure file is out of date"}},
+						{ID: sarifRuleExtra, ShortDescription: sarifMessage{Text: "Conjure file is no longer generated"}},
+						{ID: sarifRuleStaleLock, ShortDescription: sarifMessage{Text: "Conjure lockfile is out of date"}},
+					},
+				},
+			},
+			Results: results,
+		}},
+	}
+}
+
+func sarifLocations(relPath string) []sarifLocation {
+	if relPath == "" {
+		return nil
+	}
+	return []sarifLocation{{
+		PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(relPath)},
+		},
+	}}
+}
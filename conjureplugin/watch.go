@@ -0,0 +1,171 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin/yamlcompiler"
+	"github.com/pkg/errors"
+)
+
+// Watch runs the provided projects and then watches the local sources of their IR (the paths of local YAML and IR file
+// locators and the files that local YAML imports, including files outside of the locator path). When the sources of
+// projects change, the projects are run again once no further changes have been observed for the provided debounce
+// duration. Only the projects whose sources changed are run again. Sources are polled at the provided interval so that
+// changes are detected on all file systems. Errors of runs are printed to the provided writer and do not stop watching.
+// Returns nil when the provided context is done.
+//
+// The provided run parameters are applied to every run. The projects that are watched are selected by the
+// ProjectsParam of the run parameters, if any, and a run after a change is further restricted to the changed projects.
+func Watch(ctx context.Context, params ConjureProjectParams, projectDir string, stdout io.Writer, pollInterval, debounce time.Duration, runParams ...RunParam) error {
+	if pollInterval <= 0 {
+		return errors.Errorf("poll interval must be positive: %v", pollInterval)
+	}
+	runArgCollector := newRunArgs(runParams...)
+	watchedParams, err := params.FilterProjects(runArgCollector.includeProjects, runArgCollector.excludeProjects)
+	if err != nil {
+		return err
+	}
+	sourcePaths := make(map[string][]string)
+	for _, k := range watchedParams.SortedKeys {
+		if paths := localSourcePaths(watchedParams.Params[k].IRProvider); len(paths) > 0 {
+			sourcePaths[k] = paths
+		}
+	}
+
+	runProjects := func(keys []string) {
+		if err := Run(params, false, projectDir, stdout, append(append([]RunParam(nil), runParams...), onlyProjectsParam(keys))...); err != nil {
+			_, _ = fmt.Fprintf(stdout, "Conjure generation failed: %v\n", err)
+			return
+		}
+		_, _ = fmt.Fprintf(stdout, "Generated %s\n", strings.Join(keys, ", "))
+	}
+
+	fingerprints := make(map[string]sourceFingerprint)
+	for k, paths := range sourcePaths {
+		fingerprints[k] = newSourceFingerprint(paths)
+	}
+	runProjects(watchedParams.SortedKeys)
+	if len(sourcePaths) == 0 {
+		_, _ = fmt.Fprintln(stdout, "No projects have local sources to watch")
+		return nil
+	}
+	_, _ = fmt.Fprintf(stdout, "Watching local sources of %d projects for changes\n", len(sourcePaths))
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	changed := make(map[string]struct{})
+	var lastChange time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			for k, paths := range sourcePaths {
+				fingerprint := newSourceFingerprint(paths)
+				if fingerprint.equal(fingerprints[k]) {
+					continue
+				}
+				// the change may have added or removed imports, so the paths are determined again
+				if paths := localSourcePaths(watchedParams.Params[k].IRProvider); len(paths) > 0 {
+					sourcePaths[k] = paths
+					fingerprint = newSourceFingerprint(paths)
+				}
+				fingerprints[k] = fingerprint
+				changed[k] = struct{}{}
+				lastChange = now
+			}
+			if len(changed) == 0 || now.Sub(lastChange) < debounce {
+				continue
+			}
+			var changedKeys []string
+			for _, k := range watchedParams.SortedKeys {
+				if _, ok := changed[k]; ok {
+					changedKeys = append(changedKeys, k)
+				}
+			}
+			changed = make(map[string]struct{})
+			runProjects(changedKeys)
+		}
+	}
+}
+
+// localSourcePaths returns the local paths from which the provided provider reads IR or the YAML that it compiles. The
+// paths of YAML providers include the files that the YAML imports unless the YAML cannot be parsed.
+func localSourcePaths(provider IRProvider) []string {
+	switch p := provider.(type) {
+	case *localYAMLIRProvider:
+		paths := []string{p.path}
+		if files, err := yamlcompiler.InputPathFiles(p.path); err == nil {
+			paths = append(paths, files...)
+		}
+		return paths
+	case *localFileIRProvider:
+		return []string{p.path}
+	case *pinnedIRProvider:
+		return localSourcePaths(p.provider)
+	case *mergedIRProvider:
+		var paths []string
+		for _, delegate := range p.providers {
+			paths = append(paths, localSourcePaths(delegate)...)
+		}
+		return paths
+	default:
+		return nil
+	}
+}
+
+// sourceFingerprint records the size and modification time of every file in a set of source paths.
+type sourceFingerprint map[string]string
+
+// newSourceFingerprint returns the fingerprint of the provided paths. Directories are walked recursively. Paths that
+// do not exist or cannot be read are recorded as such so that their creation is detected.
+func newSourceFingerprint(paths []string) sourceFingerprint {
+	fingerprint := make(sourceFingerprint)
+	for _, sourcePath := range paths {
+		if err := filepath.Walk(sourcePath, func(currPath string, info os.FileInfo, err error) error {
+			if err != nil {
+				fingerprint[currPath] = "error: " + err.Error()
+				return nil
+			}
+			if info.Mode().IsRegular() {
+				fingerprint[currPath] = fmt.Sprintf("%d %d", info.Size(), info.ModTime().UnixNano())
+			}
+			return nil
+		}); err != nil {
+			fingerprint[sourcePath] = "error: " + err.Error()
+		}
+	}
+	return fingerprint
+}
+
+func (f sourceFingerprint) equal(other sourceFingerprint) bool {
+	if len(f) != len(other) {
+		return false
+	}
+	for k, v := range f {
+		if otherValue, ok := other[k]; !ok || otherValue != v {
+			return false
+		}
+	}
+	return true
+}
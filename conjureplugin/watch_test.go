@@ -0,0 +1,158 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, "go.mod"), []byte("module github.com/palantir/test\n"), 0644))
+	writeYAML := func(pkg, fieldType string) string {
+		yamlPath := filepath.Join(projectDir, pkg+".yml")
+		require.NoError(t, ioutil.WriteFile(yamlPath, []byte(fmt.Sprintf(`
+types:
+  definitions:
+    default-package: com.palantir.%s
+    objects:
+      Foo:
+        fields:
+          value: %s
+`, pkg, fieldType)), 0644))
+		return yamlPath
+	}
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"bar", "baz", "foo"},
+		Params:     make(map[string]conjureplugin.ConjureProjectParam),
+	}
+	for _, pkg := range params.SortedKeys {
+		params.Params[pkg] = conjureplugin.ConjureProjectParam{
+			OutputDir:  "conjure",
+			IRProvider: conjureplugin.NewLocalYAMLIRProvider(writeYAML(pkg, "string"), conjureplugin.YAMLCompilerParam(conjureplugin.YAMLCompilerNative)),
+		}
+	}
+
+	stdout := &syncBuffer{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- conjureplugin.Watch(ctx, params, projectDir, stdout, 10*time.Millisecond, 50*time.Millisecond, conjureplugin.ProjectsParam(nil, []string{"baz"}))
+	}()
+	waitForOutput := func(want string, count int) {
+		require.Eventually(t, func() bool {
+			return strings.Count(stdout.String(), want) >= count
+		}, 30*time.Second, 10*time.Millisecond, "output did not contain %q %d times:\n%s", want, count, stdout.String())
+	}
+	waitForOutput("Watching local sources of 2 projects for changes\n", 1)
+	assert.Contains(t, stdout.String(), "Generated bar, foo\n")
+
+	structsFile := func(pkg string) string {
+		return filepath.Join(projectDir, "conjure", "com", "palantir", pkg, "structs.conjure.go")
+	}
+	oldTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for _, pkg := range []string{"bar", "foo"} {
+		require.NoError(t, os.Chtimes(structsFile(pkg), oldTime, oldTime))
+	}
+
+	writeYAML("foo", "integer")
+	waitForOutput("Generated foo\n", 1)
+	content, err := ioutil.ReadFile(structsFile("foo"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Value int")
+	fi, err := os.Stat(structsFile("bar"))
+	require.NoError(t, err)
+	assert.True(t, fi.ModTime().Equal(oldTime), "project whose sources did not change was generated")
+
+	// errors are printed and watching continues
+	require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, "foo.yml"), []byte("types: ["), 0644))
+	waitForOutput("Conjure generation failed: ", 1)
+	writeYAML("foo", "string")
+	waitForOutput("Generated foo\n", 2)
+
+	// files that are imported from outside of the locator path are watched
+	commonPath := filepath.Join(projectDir, "common", "common.yml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(commonPath), 0755))
+	writeCommonYAML := func(fieldType string) {
+		require.NoError(t, ioutil.WriteFile(commonPath, []byte(fmt.Sprintf(`
+types:
+  definitions:
+    default-package: com.palantir.common
+    objects:
+      Common:
+        fields:
+          value: %s
+`, fieldType)), 0644))
+	}
+	writeCommonYAML("string")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, "foo.yml"), []byte(`
+types:
+  conjure-imports:
+    common: common/common.yml
+  definitions:
+    default-package: com.palantir.foo
+    objects:
+      Foo:
+        fields:
+          value: common.Common
+`), 0644))
+	waitForOutput("Generated foo\n", 3)
+	writeCommonYAML("integer")
+	waitForOutput("Generated foo\n", 4)
+
+	// the projects that are excluded by the run parameters are neither generated nor watched
+	writeYAML("baz", "integer")
+	time.Sleep(200 * time.Millisecond)
+	assert.NotContains(t, stdout.String(), "baz")
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(30 * time.Second):
+		require.Fail(t, "Watch did not return after its context was cancelled")
+	}
+}
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
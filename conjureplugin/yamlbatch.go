@@ -0,0 +1,242 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin/yamlcompiler"
+	"github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli"
+	"github.com/pkg/errors"
+)
+
+// yamlBatchMember is a project whose IR can be compiled together with the IR of other projects.
+type yamlBatchMember struct {
+	key      string
+	provider *localYAMLIRProvider
+	// cacheKey is the key of the IR of the project in the cache of the provider.
+	cacheKey string
+	// rootDir is the directory that contains all of the files that are compiled for the project.
+	rootDir string
+	defs    yamlcompiler.Definitions
+}
+
+// compileYAMLBatch compiles the IR of the projects that generate IR from local YAML using the Conjure CLI. The projects
+// that are compiled by the same version of the CLI with the same extensions are compiled together in a single
+// invocation of the CLI: the YAML files of every project are copied into a separate directory of a temporary directory,
+// the temporary directory is compiled and the IR of every project is extracted from the combined IR. Returns the IR of
+// every project that was compiled in this manner keyed by project key.
+//
+// Batching is an optimization, so it never fails. Projects whose IR is already cached, projects that import files
+// outside of their locator path and projects that define a type, error or service with the same qualified name as
+// another project of the batch are omitted from the result and are compiled individually when their IR is requested.
+// If the compilation of a batch fails, the batch is split in half and each half is compiled separately, so a single
+// project that cannot be compiled together with the others does not cause every project to be compiled individually.
+func compileYAMLBatch(params ConjureProjectParams) map[string][]byte {
+	var compilationKeys []string
+	batches := make(map[string][]yamlBatchMember)
+	cliParams := make(map[string][]conjureircli.Param)
+	for _, key := range params.SortedKeys {
+		provider, ok := params.Params[key].IRProvider.(*localYAMLIRProvider)
+		if !ok || (provider.compiler != "" && provider.compiler != YAMLCompilerJava) {
+			continue
+		}
+		member, memberCLIParams, compilationKey, ok := newYAMLBatchMember(key, provider)
+		if !ok {
+			continue
+		}
+		if _, ok := batches[compilationKey]; !ok {
+			compilationKeys = append(compilationKeys, compilationKey)
+			cliParams[compilationKey] = memberCLIParams
+		}
+		batches[compilationKey] = append(batches[compilationKey], member)
+	}
+
+	batchIR := make(map[string][]byte)
+	for _, compilationKey := range compilationKeys {
+		compileYAMLBatchOrSplit(withoutCollidingMembers(batches[compilationKey]), cliParams[compilationKey], batchIR)
+	}
+	return batchIR
+}
+
+// compileYAMLBatchOrSplit compiles the provided members together and adds the IR of every member to the provided map.
+// If the compilation fails, the first and second half of the members are compiled separately in the same manner. A
+// batch with fewer than 2 members is not compiled.
+func compileYAMLBatchOrSplit(members []yamlBatchMember, cliParams []conjureircli.Param, batchIR map[string][]byte) {
+	if len(members) < 2 {
+		return
+	}
+	memberIRs, err := compileYAMLBatchMembers(members, cliParams)
+	if err != nil {
+		compileYAMLBatchOrSplit(members[:len(members)/2], cliParams, batchIR)
+		compileYAMLBatchOrSplit(members[len(members)/2:], cliParams, batchIR)
+		return
+	}
+	for i, member := range members {
+		batchIR[member.key] = memberIRs[i]
+		if member.provider.cache != nil {
+			_ = member.provider.cache.storeCompiled(member.cacheKey, memberIRs[i])
+		}
+	}
+}
+
+// withoutCollidingMembers returns the provided members without the members that define a type, error or service with
+// the same qualified name as another member. Compiling such members together fails, so they are compiled individually.
+func withoutCollidingMembers(members []yamlBatchMember) []yamlBatchMember {
+	definingMembers := make(map[yamlcompiler.QualifiedName]int)
+	for _, member := range members {
+		for _, name := range member.qualifiedNames() {
+			definingMembers[name]++
+		}
+	}
+	var result []yamlBatchMember
+	for _, member := range members {
+		colliding := false
+		for _, name := range member.qualifiedNames() {
+			if definingMembers[name] > 1 {
+				colliding = true
+				break
+			}
+		}
+		if !colliding {
+			result = append(result, member)
+		}
+	}
+	return result
+}
+
+// qualifiedNames returns the distinct qualified names of the types, errors and services of the member.
+func (m yamlBatchMember) qualifiedNames() []yamlcompiler.QualifiedName {
+	seen := make(map[yamlcompiler.QualifiedName]struct{})
+	var names []yamlcompiler.QualifiedName
+	for _, defs := range [][]yamlcompiler.QualifiedName{m.defs.Types, m.defs.Errors, m.defs.Services} {
+		for _, name := range defs {
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// newYAMLBatchMember returns the batch member for the provided project along with the parameters with which the CLI is
+// run and the compilation key of the CLI for those parameters. Returns false if the project cannot be compiled in a
+// batch or if its IR is already cached.
+func newYAMLBatchMember(key string, provider *localYAMLIRProvider) (yamlBatchMember, []conjureircli.Param, string, bool) {
+	cliParams, err := provider.allCLIParams()
+	if err != nil {
+		return yamlBatchMember{}, nil, "", false
+	}
+	compilationKey, err := conjureircli.CompilationKey(cliParams...)
+	if err != nil {
+		return yamlBatchMember{}, nil, "", false
+	}
+	member := yamlBatchMember{
+		key:      key,
+		provider: provider,
+	}
+	if provider.cache != nil {
+		if member.cacheKey, err = provider.compiledCacheKey(compilationKey); err != nil {
+			return yamlBatchMember{}, nil, "", false
+		}
+		if _, ok := provider.cache.lookupCompiled(member.cacheKey); ok {
+			return yamlBatchMember{}, nil, "", false
+		}
+	}
+
+	if member.defs, err = yamlcompiler.InputPathDefinitions(provider.path); err != nil {
+		return yamlBatchMember{}, nil, "", false
+	}
+	member.rootDir = provider.path
+	if fi, err := os.Stat(provider.path); err != nil {
+		return yamlBatchMember{}, nil, "", false
+	} else if !fi.IsDir() {
+		member.rootDir = filepath.Dir(provider.path)
+	}
+	for _, f := range member.defs.Files {
+		if _, ok := relPathWithin(member.rootDir, f); !ok {
+			return yamlBatchMember{}, nil, "", false
+		}
+	}
+	return member, cliParams, compilationKey, true
+}
+
+// compileYAMLBatchMembers compiles the provided members in a single invocation of the CLI and returns the IR of each
+// member.
+func compileYAMLBatchMembers(members []yamlBatchMember, cliParams []conjureircli.Param) (rIRs [][]byte, rErr error) {
+	tmpDir, err := ioutil.TempDir("", "conjure-batch-")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() {
+		_ = os.RemoveAll(tmpDir)
+	}()
+
+	for i, member := range members {
+		memberDir := filepath.Join(tmpDir, fmt.Sprintf("project-%d", i))
+		for _, f := range member.defs.Files {
+			relPath, _ := relPathWithin(member.rootDir, f)
+			content, err := ioutil.ReadFile(f)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			dst := filepath.Join(memberDir, relPath)
+			if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			if err := ioutil.WriteFile(dst, content, 0644); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+	}
+
+	unionIR, err := conjureircli.InputPathToIRWithParams(tmpDir, cliParams...)
+	if err != nil {
+		return nil, err
+	}
+	var irs [][]byte
+	for _, member := range members {
+		ir, err := yamlcompiler.FilterIR(unionIR, member.defs)
+		if err != nil {
+			return nil, err
+		}
+		irs = append(irs, ir)
+	}
+	return irs, nil
+}
+
+// relPathWithin returns the path of the provided file relative to the provided directory. Returns false if the file is
+// not within the directory.
+func relPathWithin(dir, file string) (string, bool) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return "", false
+	}
+	relPath, err := filepath.Rel(absDir, absFile)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return relPath, true
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureplugin_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin"
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin/yamlcompiler"
+	"github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCompilesYAMLInBatch(t *testing.T) {
+	projectDir := t.TempDir()
+	for path, content := range map[string]string{
+		"go.mod": "module github.com/palantir/test\n",
+		"a/api.yml": `
+types:
+  conjure-imports:
+    common: common/common.yml
+  definitions:
+    default-package: com.palantir.a
+    objects:
+      A: { fields: { value: common.Common } }
+`,
+		"a/common/common.yml": `
+types:
+  definitions:
+    default-package: com.palantir.common
+    objects:
+      Common: { alias: string }
+`,
+		"b/api.yml": `
+types:
+  definitions:
+    default-package: com.palantir.b
+    objects:
+      B: { values: [ONE, TWO] }
+    errors:
+      BNotFound: { namespace: B, code: NOT_FOUND }
+services:
+  BService:
+    package: com.palantir.b
+    endpoints:
+      getB:
+        http: GET /b
+        returns: B
+`,
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Join(projectDir, filepath.Dir(path)), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, path), []byte(content), 0644))
+	}
+
+	// the fake CLI records the input of every compilation and writes the IR that the native compiler produces for the
+	// input, which is the IR that the Conjure CLI would produce for it: the IR of the batch directory is the IR of a
+	// directory that contains the files of every project in a separate directory, and the IR of a project directory is
+	// written next to the directory.
+	unionDir := filepath.Join(projectDir, "union")
+	for i, project := range []string{"a", "b"} {
+		projectIR, err := yamlcompiler.InputPathToIR(filepath.Join(projectDir, project))
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, project+".json"), projectIR, 0644))
+		files, err := yamlcompiler.InputPathFiles(filepath.Join(projectDir, project))
+		require.NoError(t, err)
+		for _, f := range files {
+			relPath, err := filepath.Rel(filepath.Join(projectDir, project), f)
+			require.NoError(t, err)
+			content, err := ioutil.ReadFile(f)
+			require.NoError(t, err)
+			dst := filepath.Join(unionDir, fmt.Sprintf("project-%d", i), relPath)
+			require.NoError(t, os.MkdirAll(filepath.Dir(dst), 0755))
+			require.NoError(t, ioutil.WriteFile(dst, content, 0644))
+		}
+	}
+	unionIR, err := yamlcompiler.InputPathToIR(unionDir)
+	require.NoError(t, err)
+	unionIRPath := filepath.Join(projectDir, "union.json")
+	require.NoError(t, ioutil.WriteFile(unionIRPath, unionIR, 0644))
+	invocationsFile := filepath.Join(projectDir, "invocations")
+	cliPath := filepath.Join(projectDir, "conjure")
+	require.NoError(t, ioutil.WriteFile(cliPath, []byte(`#!/bin/sh
+if [ "$1" = "--version" ]; then
+  echo "4.40.0"
+  exit 0
+fi
+echo "$2" >> "`+invocationsFile+`"
+if [ -f "$2.json" ]; then
+  cp "$2.json" "$3"
+else
+  cp "`+unionIRPath+`" "$3"
+fi
+`), 0755))
+
+	// the IR compiled in the batch is cached, so the providers return it afterwards without running the CLI
+	cache := conjureplugin.NewIRCache(filepath.Join(projectDir, "cache"))
+	yamlParams := []conjureplugin.YAMLParam{
+		conjureplugin.YAMLCLIParams(conjureircli.CLIPathParam(cliPath)),
+		conjureplugin.YAMLCacheParam(cache),
+	}
+	params := conjureplugin.ConjureProjectParams{
+		SortedKeys: []string{"a", "b"},
+		Params: map[string]conjureplugin.ConjureProjectParam{
+			"a": {
+				OutputDir:  "out-a",
+				IRProvider: conjureplugin.NewLocalYAMLIRProvider(filepath.Join(projectDir, "a"), yamlParams...),
+			},
+			"b": {
+				OutputDir:  "out-b",
+				IRProvider: conjureplugin.NewLocalYAMLIRProvider(filepath.Join(projectDir, "b"), yamlParams...),
+			},
+		},
+	}
+	lockfilePath := filepath.Join(projectDir, conjureplugin.LockfileName)
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}, conjureplugin.LockfileParam(lockfilePath)))
+
+	invocations, err := ioutil.ReadFile(invocationsFile)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(invocations), "\n"), "expected a single compilation, got:\n%s", invocations)
+
+	// the IR of every project is byte for byte the IR that compiling the project on its own produces
+	lockfile, exists, err := conjureplugin.ReadLockfile(lockfilePath)
+	require.NoError(t, err)
+	require.True(t, exists)
+	for _, project := range []string{"a", "b"} {
+		batchIR, err := params.Params[project].IRProvider.IRBytes()
+		require.NoError(t, err)
+		wantIR, err := ioutil.ReadFile(filepath.Join(projectDir, project+".json"))
+		require.NoError(t, err)
+		assert.Equal(t, string(wantIR), string(batchIR), "Project %s", project)
+		assert.Equal(t, lockedIRDigest(t, wantIR), lockfile.Projects[project].IRSHA256, "Project %s", project)
+	}
+	invocations, err = ioutil.ReadFile(invocationsFile)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(invocations), "\n"), "expected a single compilation, got:\n%s", invocations)
+}
+
+func TestRunCompilesYAMLInBatchWithoutFailingProjects(t *testing.T) {
+	projectDir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module github.com/palantir/test\n",
+		// d and e define the same type, so they cannot be compiled together
+		"d/dup.yml": "types: { definitions: { default-package: com.palantir.dup, objects: { Dup: { alias: string } } } }\n",
+		"e/dup.yml": "types: { definitions: { default-package: com.palantir.dup, objects: { Dup: { alias: string } } } }\n",
+		// the fake CLI fails to compile x together with other projects
+		"x/broken.yml": "types: { definitions: { default-package: com.palantir.x, objects: { X: { alias: string } } } }\n",
+	}
+	for _, project := range []string{"a", "b", "c"} {
+		files[project+"/api.yml"] = fmt.Sprintf("types: { definitions: { default-package: com.palantir.%s, objects: { %s: { alias: string } } } }\n", project, strings.ToUpper(project))
+	}
+	for path, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Join(projectDir, filepath.Dir(path)), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(projectDir, path), []byte(content), 0644))
+	}
+
+	invocationsFile := filepath.Join(projectDir, "invocations")
+	cliPath := filepath.Join(projectDir, "conjure")
+	require.NoError(t, ioutil.WriteFile(cliPath, []byte(`#!/bin/sh
+if [ "$1" = "--version" ]; then
+  echo "4.40.0"
+  exit 0
+fi
+echo "$2" >> "`+invocationsFile+`"
+if [ "$(find "$2" -name dup.yml | wc -l)" -gt 1 ] || { [ "$(find "$2" -name '*.yml' | wc -l)" -gt 1 ] && [ -n "$(find "$2" -name broken.yml)" ]; }; then
+  echo "Failed to compile" >&2
+  exit 1
+fi
+echo '`+testIRJSON+`' > "$3"
+`), 0755))
+
+	yamlParams := []conjureplugin.YAMLParam{conjureplugin.YAMLCLIParams(conjureircli.CLIPathParam(cliPath))}
+	params := conjureplugin.ConjureProjectParams{
+		Params: make(map[string]conjureplugin.ConjureProjectParam),
+	}
+	for _, project := range []string{"a", "b", "c", "d", "e", "x"} {
+		params.SortedKeys = append(params.SortedKeys, project)
+		params.Params[project] = conjureplugin.ConjureProjectParam{
+			OutputDir:  "out-" + project,
+			IRProvider: conjureplugin.NewLocalYAMLIRProvider(filepath.Join(projectDir, project), yamlParams...),
+		}
+	}
+	require.NoError(t, conjureplugin.Run(params, false, projectDir, &bytes.Buffer{}))
+
+	invocations, err := ioutil.ReadFile(invocationsFile)
+	require.NoError(t, err)
+	var batchInvocations int
+	var individualProjects []string
+	for _, inPath := range strings.Split(strings.TrimSpace(string(invocations)), "\n") {
+		if project, err := filepath.Rel(projectDir, inPath); err == nil && !strings.HasPrefix(project, "..") {
+			individualProjects = append(individualProjects, project)
+		} else {
+			batchInvocations++
+		}
+	}
+	// a, b, c and x are compiled together, which fails, and are then split into a and b, which are compiled together,
+	// and c and x, which fail again. d and e are never compiled together.
+	assert.Equal(t, 3, batchInvocations, "invocations:\n%s", invocations)
+	assert.ElementsMatch(t, []string{"c", "d", "e", "x"}, individualProjects, "invocations:\n%s", invocations)
+}
+
+// lockedIRDigest returns the digest that the lockfile records for the provided IR, which excludes its extensions.
+func lockedIRDigest(t *testing.T, irBytes []byte) string {
+	var ir map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(irBytes, &ir))
+	delete(ir, "extensions")
+	strippedIRBytes, err := json.Marshal(ir)
+	require.NoError(t, err)
+	return fmt.Sprintf("%x", sha256.Sum256(strippedIRBytes))
+}
@@ -0,0 +1,323 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package yamlcompiler compiles Conjure YAML definitions into Conjure IR without requiring the Java Conjure CLI. The IR
+// that it produces is formatted in the same manner as the IR produced by the Conjure CLI.
+package yamlcompiler
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/palantir/pkg/safejson"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// irVersion is the version of the IR produced by the compiler.
+const irVersion = 1
+
+type compileArgs struct {
+	extensions interface{}
+}
+
+type Param interface {
+	apply(*compileArgs)
+}
+
+type paramFn func(*compileArgs)
+
+func (fn paramFn) apply(c *compileArgs) {
+	fn(c)
+}
+
+// ExtensionsParam returns a parameter that sets the extensions of the generated Conjure IR to be the content of the
+// provided map if it is non-empty. Returns a no-op parameter if the provided map is nil or empty.
+func ExtensionsParam(extensionsContent map[string]interface{}) (Param, error) {
+	if len(extensionsContent) == 0 {
+		return nil, nil
+	}
+	extensionBytes, err := safejson.Marshal(extensionsContent)
+	if err != nil {
+		return nil, err
+	}
+	extensions, err := parseJSON(extensionBytes)
+	if err != nil {
+		return nil, err
+	}
+	return paramFn(func(c *compileArgs) {
+		c.extensions = extensions
+	}), nil
+}
+
+func YAMLtoIR(in []byte) ([]byte, error) {
+	return YAMLtoIRWithParams(in)
+}
+
+// YAMLtoIRWithParams returns the IR for the provided Conjure YAML. Conjure imports are resolved relative to the working
+// directory.
+func YAMLtoIRWithParams(in []byte, params ...Param) ([]byte, error) {
+	c := newCompiler()
+	if _, err := c.load("in.yml", in); err != nil {
+		return nil, err
+	}
+	return c.compile(params...)
+}
+
+func InputPathToIR(inPath string) ([]byte, error) {
+	return InputPathToIRWithParams(inPath)
+}
+
+// InputPathToIRWithParams returns the IR for the Conjure YAML at the provided path, which is either a single file or a
+// directory. If it is a directory, all of the files with the extension ".yml" in the directory and its subdirectories
+// are compiled together.
+func InputPathToIRWithParams(inPath string, params ...Param) ([]byte, error) {
+	c, err := loadInputPath(inPath)
+	if err != nil {
+		return nil, err
+	}
+	return c.compile(params...)
+}
+
+// loadInputPath returns a compiler that has loaded the input files for the provided path.
+func loadInputPath(inPath string) (*compiler, error) {
+	inputFiles, err := InputFiles(inPath)
+	if err != nil {
+		return nil, err
+	}
+	c := newCompiler()
+	for _, inputFile := range inputFiles {
+		content, err := ioutil.ReadFile(inputFile)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if _, err := c.load(inputFile, content); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// InputFiles returns the Conjure YAML files that are compiled for the provided path in sorted order. If the path is a
+// file, it is returned. If it is a directory, the files in the directory and its subdirectories with the extension
+// ".yml" are returned.
+func InputFiles(inPath string) ([]string, error) {
+	fi, err := os.Stat(inPath)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if !fi.IsDir() {
+		return []string{inPath}, nil
+	}
+	var inputFiles []string
+	if err := filepath.Walk(inPath, func(currPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() && strings.HasSuffix(info.Name(), ".yml") {
+			inputFiles = append(inputFiles, currPath)
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(inputFiles) == 0 {
+		return nil, errors.Errorf("directory %s does not contain any Conjure YAML files", inPath)
+	}
+	sort.Strings(inputFiles)
+	return inputFiles, nil
+}
+
+type compiler struct {
+	files       []*sourceFile
+	filesByPath map[string]*sourceFile
+}
+
+func newCompiler() *compiler {
+	return &compiler{
+		filesByPath: make(map[string]*sourceFile),
+	}
+}
+
+// load parses the provided content of the file at the provided path along with the files that it imports. Files that
+// were already loaded are not parsed again.
+func (c *compiler) load(path string, content []byte) (*sourceFile, error) {
+	key := filepath.Clean(path)
+	if absPath, err := filepath.Abs(path); err == nil {
+		key = absPath
+	}
+	if f, ok := c.filesByPath[key]; ok {
+		return f, nil
+	}
+
+	f := &sourceFile{
+		path:            path,
+		conjureImports:  make(map[string]*sourceFile),
+		externalImports: make(map[string]interface{}),
+		localTypes:      make(map[string]typeName),
+	}
+	c.filesByPath[key] = f
+	c.files = append(c.files, f)
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", path)
+	}
+	f.root = &yaml.Node{Kind: yaml.MappingNode}
+	if len(doc.Content) > 0 && !isNull(doc.Content[0]) {
+		f.root = doc.Content[0]
+	}
+	if err := f.checkMapping(f.root, "types", "services"); err != nil {
+		return nil, err
+	}
+	if typesNode := mappingValue(f.root, "types"); typesNode != nil {
+		if err := f.checkMapping(typesNode, "conjure-imports", "imports", "definitions"); err != nil {
+			return nil, err
+		}
+		if importsNode := mappingValue(typesNode, "conjure-imports"); importsNode != nil {
+			if err := f.checkMapping(importsNode); err != nil {
+				return nil, err
+			}
+			for _, entry := range mappingEntries(importsNode) {
+				importPath, err := f.scalar(entry.value, "conjure import")
+				if err != nil {
+					return nil, err
+				}
+				importPath = filepath.Join(filepath.Dir(path), filepath.FromSlash(importPath))
+				importContent, err := ioutil.ReadFile(importPath)
+				if err != nil {
+					return nil, f.errorf(entry.value, "failed to read conjure import %s: %v", importPath, err)
+				}
+				imported, err := c.load(importPath, importContent)
+				if err != nil {
+					return nil, err
+				}
+				f.conjureImports[entry.key.Value] = imported
+			}
+		}
+	}
+	return f, nil
+}
+
+// compile returns the IR for all of the loaded files.
+func (c *compiler) compile(params ...Param) ([]byte, error) {
+	var compileArgCollector compileArgs
+	for _, param := range params {
+		if param == nil {
+			continue
+		}
+		param.apply(&compileArgCollector)
+	}
+
+	types, errorDefs, services, err := c.definitions()
+	if err != nil {
+		return nil, err
+	}
+	extensions := compileArgCollector.extensions
+	if extensions == nil {
+		extensions = newJSONObject()
+	}
+	def := newJSONObject().
+		set("version", irVersion).
+		set("errors", sortedDefinitions(errorDefs)).
+		set("types", sortedDefinitions(types)).
+		set("services", sortedDefinitions(services)).
+		set("extensions", extensions)
+
+	buf := &bytes.Buffer{}
+	writeJSON(buf, def)
+	return buf.Bytes(), nil
+}
+
+// definitions returns the type, error and service definitions of all of the loaded files.
+func (c *compiler) definitions() (types, errorDefs, services []namedDefinition, rErr error) {
+	definedTypes := make(map[typeName]*sourceFile)
+	for _, f := range c.files {
+		if err := f.collectLocalTypes(); err != nil {
+			return nil, nil, nil, err
+		}
+		for _, name := range f.localTypes {
+			if other, ok := definedTypes[name]; ok {
+				return nil, nil, nil, errors.Errorf("type %s is defined in both %s and %s", name, other.path, f.path)
+			}
+			definedTypes[name] = f
+		}
+	}
+	for _, f := range c.files {
+		if err := f.collectExternalImports(); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	definedErrors := make(map[typeName]*sourceFile)
+	definedServices := make(map[typeName]*sourceFile)
+	for _, f := range c.files {
+		fileTypes, err := f.typeDefinitions()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		types = append(types, fileTypes...)
+
+		fileErrors, err := f.errorDefinitions()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for _, errorDef := range fileErrors {
+			if other, ok := definedErrors[errorDef.name]; ok {
+				return nil, nil, nil, errors.Errorf("error %s is defined in both %s and %s", errorDef.name, other.path, f.path)
+			}
+			definedErrors[errorDef.name] = f
+		}
+		errorDefs = append(errorDefs, fileErrors...)
+
+		fileServices, err := f.serviceDefinitions()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for _, serviceDef := range fileServices {
+			if other, ok := definedServices[serviceDef.name]; ok {
+				return nil, nil, nil, errors.Errorf("service %s is defined in both %s and %s", serviceDef.name, other.path, f.path)
+			}
+			definedServices[serviceDef.name] = f
+		}
+		services = append(services, fileServices...)
+	}
+	return types, errorDefs, services, nil
+}
+
+// namedDefinition is the IR for a definition along with its name.
+type namedDefinition struct {
+	name  typeName
+	value *jsonObject
+}
+
+// sortedDefinitions returns the values of the provided definitions sorted by name and then by package, which is the
+// order in which the Conjure compiler normalizes definitions.
+func sortedDefinitions(defs []namedDefinition) []interface{} {
+	sort.SliceStable(defs, func(i, j int) bool {
+		if defs[i].name.name != defs[j].name.name {
+			return defs[i].name.name < defs[j].name.name
+		}
+		return defs[i].name.pkg < defs[j].name.pkg
+	})
+	values := []interface{}{}
+	for _, def := range defs {
+		values = append(values, def.value)
+	}
+	return values
+}
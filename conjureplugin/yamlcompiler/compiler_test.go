@@ -0,0 +1,515 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yamlcompiler_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/godel-conjure-plugin/v6/conjureplugin/yamlcompiler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestYAMLtoIR(t *testing.T) {
+	for i, tc := range []struct {
+		in     string
+		params []yamlcompiler.Param
+		want   string
+	}{
+		{
+			in: `
+types:
+  definitions:
+    default-package: com.palantir.conjure
+    objects:
+      BooleanExample: { fields: { value: boolean } }
+`,
+			want: `{
+  "version" : 1,
+  "errors" : [ ],
+  "types" : [ {
+    "type" : "object",
+    "object" : {
+      "typeName" : {
+        "name" : "BooleanExample",
+        "package" : "com.palantir.conjure"
+      },
+      "fields" : [ {
+        "fieldName" : "value",
+        "type" : {
+          "type" : "primitive",
+          "primitive" : "BOOLEAN"
+        }
+      } ]
+    }
+  } ],
+  "services" : [ ],
+  "extensions" : { }
+}`,
+		},
+		{
+			in: `
+types:
+  definitions:
+    default-package: com.palantir.conjure
+    objects:
+      BooleanExample: { fields: { value: boolean } }
+`,
+			params: []yamlcompiler.Param{
+				mustExtensionsParam(map[string]interface{}{
+					"recommended-product-dependencies": []map[string]interface{}{
+						{
+							"product-group":   "com.palantir.assetserver",
+							"product-name":    "asset-server",
+							"minimum-version": "2.78.0",
+							"maximum-version": "2.x.x",
+						},
+					},
+				}),
+			},
+			want: `{
+  "version" : 1,
+  "errors" : [ ],
+  "types" : [ {
+    "type" : "object",
+    "object" : {
+      "typeName" : {
+        "name" : "BooleanExample",
+        "package" : "com.palantir.conjure"
+      },
+      "fields" : [ {
+        "fieldName" : "value",
+        "type" : {
+          "type" : "primitive",
+          "primitive" : "BOOLEAN"
+        }
+      } ]
+    }
+  } ],
+  "services" : [ ],
+  "extensions" : {
+    "recommended-product-dependencies" : [ {
+      "maximum-version" : "2.x.x",
+      "minimum-version" : "2.78.0",
+      "product-group" : "com.palantir.assetserver",
+      "product-name" : "asset-server"
+    } ]
+  }
+}`,
+		},
+	} {
+		got, err := yamlcompiler.YAMLtoIRWithParams([]byte(tc.in), tc.params...)
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, tc.want, string(got), "Case %d\nGot:\n%s", i, got)
+	}
+}
+
+func TestInputPathToIR(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "common.yml"), []byte(`
+types:
+  imports:
+    ResourceIdentifier:
+      base-type: string
+      external:
+        java: com.palantir.ri.ResourceIdentifier
+  definitions:
+    default-package: com.palantir.common
+    objects:
+      Status:
+        values:
+          - ACTIVE
+          - value: RETIRED
+            deprecated: Use ACTIVE.
+`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "api.yml"), []byte(`
+types:
+  conjure-imports:
+    common: common.yml
+  definitions:
+    default-package: com.palantir.api
+    objects:
+      DatasetId:
+        alias: common.ResourceIdentifier
+      Dataset:
+        docs: A dataset.
+        fields:
+          id: DatasetId
+          tags:
+            type: map<string, set<string>>
+            docs: The tags of the dataset.
+          status: optional<common.Status>
+    errors:
+      DatasetNotFound:
+        namespace: Catalog
+        code: NOT_FOUND
+        safe-args:
+          datasetId: DatasetId
+services:
+  CatalogService:
+    name: Catalog Service
+    package: com.palantir.api.service
+    base-path: /catalog
+    default-auth: header
+    endpoints:
+      getDataset:
+        http: GET /datasets/{datasetId}
+        args:
+          datasetId: DatasetId
+          version:
+            type: optional<integer>
+            param-type: query
+        returns: Dataset
+        markers:
+          - common.ResourceIdentifier
+      ping:
+        http: POST /ping
+        auth: cookie:SESSION
+        args:
+          body: binary
+        tags: [health]
+`), 0644))
+
+	got, err := yamlcompiler.InputPathToIR(dir)
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "version" : 1,
+  "errors" : [ {
+    "errorName" : {
+      "name" : "DatasetNotFound",
+      "package" : "com.palantir.api"
+    },
+    "namespace" : "Catalog",
+    "code" : "NOT_FOUND",
+    "safeArgs" : [ {
+      "fieldName" : "datasetId",
+      "type" : {
+        "type" : "reference",
+        "reference" : {
+          "name" : "DatasetId",
+          "package" : "com.palantir.api"
+        }
+      }
+    } ],
+    "unsafeArgs" : [ ]
+  } ],
+  "types" : [ {
+    "type" : "object",
+    "object" : {
+      "typeName" : {
+        "name" : "Dataset",
+        "package" : "com.palantir.api"
+      },
+      "fields" : [ {
+        "fieldName" : "id",
+        "type" : {
+          "type" : "reference",
+          "reference" : {
+            "name" : "DatasetId",
+            "package" : "com.palantir.api"
+          }
+        }
+      }, {
+        "fieldName" : "tags",
+        "type" : {
+          "type" : "map",
+          "map" : {
+            "keyType" : {
+              "type" : "primitive",
+              "primitive" : "STRING"
+            },
+            "valueType" : {
+              "type" : "set",
+              "set" : {
+                "itemType" : {
+                  "type" : "primitive",
+                  "primitive" : "STRING"
+                }
+              }
+            }
+          }
+        },
+        "docs" : "The tags of the dataset."
+      }, {
+        "fieldName" : "status",
+        "type" : {
+          "type" : "optional",
+          "optional" : {
+            "itemType" : {
+              "type" : "reference",
+              "reference" : {
+                "name" : "Status",
+                "package" : "com.palantir.common"
+              }
+            }
+          }
+        }
+      } ],
+      "docs" : "A dataset."
+    }
+  }, {
+    "type" : "alias",
+    "alias" : {
+      "typeName" : {
+        "name" : "DatasetId",
+        "package" : "com.palantir.api"
+      },
+      "alias" : {
+        "type" : "external",
+        "external" : {
+          "externalReference" : {
+            "name" : "ResourceIdentifier",
+            "package" : "com.palantir.ri"
+          },
+          "fallback" : {
+            "type" : "primitive",
+            "primitive" : "STRING"
+          }
+        }
+      }
+    }
+  }, {
+    "type" : "enum",
+    "enum" : {
+      "typeName" : {
+        "name" : "Status",
+        "package" : "com.palantir.common"
+      },
+      "values" : [ {
+        "value" : "ACTIVE"
+      }, {
+        "value" : "RETIRED",
+        "deprecated" : "Use ACTIVE."
+      } ]
+    }
+  } ],
+  "services" : [ {
+    "serviceName" : {
+      "name" : "CatalogService",
+      "package" : "com.palantir.api.service"
+    },
+    "endpoints" : [ {
+      "endpointName" : "getDataset",
+      "httpMethod" : "GET",
+      "httpPath" : "/catalog/datasets/{datasetId}",
+      "auth" : {
+        "type" : "header",
+        "header" : { }
+      },
+      "args" : [ {
+        "argName" : "datasetId",
+        "type" : {
+          "type" : "reference",
+          "reference" : {
+            "name" : "DatasetId",
+            "package" : "com.palantir.api"
+          }
+        },
+        "paramType" : {
+          "type" : "path",
+          "path" : { }
+        },
+        "markers" : [ ],
+        "tags" : [ ]
+      }, {
+        "argName" : "version",
+        "type" : {
+          "type" : "optional",
+          "optional" : {
+            "itemType" : {
+              "type" : "primitive",
+              "primitive" : "INTEGER"
+            }
+          }
+        },
+        "paramType" : {
+          "type" : "query",
+          "query" : {
+            "paramId" : "version"
+          }
+        },
+        "markers" : [ ],
+        "tags" : [ ]
+      } ],
+      "returns" : {
+        "type" : "reference",
+        "reference" : {
+          "name" : "Dataset",
+          "package" : "com.palantir.api"
+        }
+      },
+      "markers" : [ {
+        "type" : "external",
+        "external" : {
+          "externalReference" : {
+            "name" : "ResourceIdentifier",
+            "package" : "com.palantir.ri"
+          },
+          "fallback" : {
+            "type" : "primitive",
+            "primitive" : "STRING"
+          }
+        }
+      } ],
+      "tags" : [ ]
+    }, {
+      "endpointName" : "ping",
+      "httpMethod" : "POST",
+      "httpPath" : "/catalog/ping",
+      "auth" : {
+        "type" : "cookie",
+        "cookie" : {
+          "cookieName" : "SESSION"
+        }
+      },
+      "args" : [ {
+        "argName" : "body",
+        "type" : {
+          "type" : "primitive",
+          "primitive" : "BINARY"
+        },
+        "paramType" : {
+          "type" : "body",
+          "body" : { }
+        },
+        "markers" : [ ],
+        "tags" : [ ]
+      } ],
+      "markers" : [ ],
+      "tags" : [ "health" ]
+    } ]
+  } ],
+  "extensions" : { }
+}`, string(got), "Got:\n%s", got)
+}
+
+func TestYAMLtoIRErrors(t *testing.T) {
+	for i, tc := range []struct {
+		in      string
+		wantErr string
+	}{
+		{
+			in: `
+types:
+  definitions:
+    default-package: com.palantir.conjure
+    objects:
+      Example: { fields: { value: Unknown } }
+`,
+			wantErr: `in.yml:6:35: unknown type "Unknown"`,
+		},
+		{
+			in: `
+types:
+  definitions:
+    objects:
+      Example: { fields: { value: string } }
+`,
+			wantErr: `in.yml:5:7: Example must specify a package because the file does not specify a default-package`,
+		},
+		{
+			in: `
+services:
+  TestService:
+    package: com.palantir.conjure
+    endpoints:
+      get:
+        http: GET /{id}
+`,
+			wantErr: `in.yml:7:9: path parameters of endpoint get do not have corresponding arguments: id`,
+		},
+		{
+			in: `
+types:
+  definitions:
+    default-package: com.palantir.conjure
+    objects:
+      Example: { field: { value: string } }
+`,
+			wantErr: `in.yml:6:7: type Example must specify one of alias, values, union or fields`,
+		},
+	} {
+		_, err := yamlcompiler.YAMLtoIR([]byte(tc.in))
+		assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+	}
+}
+
+func TestFilterIR(t *testing.T) {
+	unionDir := t.TempDir()
+	for _, f := range []struct {
+		path    string
+		content string
+	}{
+		{"a/api.yml", `
+types:
+  conjure-imports:
+    common: common/common.yml
+  definitions:
+    default-package: com.palantir.a
+    objects:
+      A: { fields: { value: common.Common } }
+`},
+		{"a/common/common.yml", `
+types:
+  definitions:
+    default-package: com.palantir.common
+    objects:
+      Common: { alias: string }
+`},
+		{"b/api.yml", `
+types:
+  definitions:
+    default-package: com.palantir.b
+    objects:
+      B: { values: [ONE, TWO] }
+    errors:
+      BNotFound: { namespace: B, code: NOT_FOUND }
+services:
+  BService:
+    package: com.palantir.b
+    endpoints:
+      getB:
+        http: GET /b
+        returns: B
+`},
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(unionDir, f.path)), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(unionDir, f.path), []byte(f.content), 0644))
+	}
+	unionIR, err := yamlcompiler.InputPathToIR(unionDir)
+	require.NoError(t, err)
+
+	for _, project := range []string{"a", "b", "a/api.yml"} {
+		projectPath := filepath.Join(unionDir, project)
+		want, err := yamlcompiler.InputPathToIR(projectPath)
+		require.NoError(t, err)
+		defs, err := yamlcompiler.InputPathDefinitions(projectPath)
+		require.NoError(t, err)
+		got, err := yamlcompiler.FilterIR(unionIR, defs)
+		require.NoError(t, err)
+		assert.Equal(t, string(want), string(got), "Project %s", project)
+	}
+}
+
+func mustExtensionsParam(in map[string]interface{}) yamlcompiler.Param {
+	param, err := yamlcompiler.ExtensionsParam(in)
+	if err != nil {
+		panic(err)
+	}
+	return param
+}
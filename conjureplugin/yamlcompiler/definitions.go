@@ -0,0 +1,141 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yamlcompiler
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+)
+
+// QualifiedName is the name and package of a Conjure type, error or service.
+type QualifiedName struct {
+	Name    string
+	Package string
+}
+
+// Definitions describes the definitions that are compiled from Conjure YAML.
+type Definitions struct {
+	// Files are the paths of the files that are compiled, including the files that they import.
+	Files    []string
+	Types    []QualifiedName
+	Errors   []QualifiedName
+	Services []QualifiedName
+}
+
+// InputPathDefinitions returns the definitions that are compiled from the Conjure YAML at the provided path, which is
+// either a single file or a directory, in the same manner as InputPathToIR.
+func InputPathDefinitions(inPath string) (Definitions, error) {
+	c, err := loadInputPath(inPath)
+	if err != nil {
+		return Definitions{}, err
+	}
+	types, errorDefs, services, err := c.definitions()
+	if err != nil {
+		return Definitions{}, err
+	}
+	var defs Definitions
+	for _, f := range c.files {
+		defs.Files = append(defs.Files, f.path)
+	}
+	for _, curr := range []struct {
+		namedDefs []namedDefinition
+		dst       *[]QualifiedName
+	}{
+		{types, &defs.Types},
+		{errorDefs, &defs.Errors},
+		{services, &defs.Services},
+	} {
+		for _, def := range curr.namedDefs {
+			*curr.dst = append(*curr.dst, QualifiedName{Name: def.name.name, Package: def.name.pkg})
+		}
+	}
+	return defs, nil
+}
+
+// InputPathFiles returns the paths of the files that are read to compile the Conjure YAML at the provided path, which
+// is either a single file or a directory, in the same manner as InputPathToIR: the input files and every file that they
+// import using "conjure-imports", directly or transitively. The files are parsed, but the definitions in them are not
+// checked.
+func InputPathFiles(inPath string) ([]string, error) {
+	c, err := loadInputPath(inPath)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, f := range c.files {
+		files = append(files, f.path)
+	}
+	return files, nil
+}
+
+// FilterIR returns the provided IR with only the types, errors and services of the provided definitions. The IR is
+// formatted in the same manner as the IR produced by the Conjure CLI, so filtering IR produced by the CLI for a set of
+// files that is a superset of the files of the definitions produces the same IR as compiling only the files of the
+// definitions.
+func FilterIR(ir []byte, defs Definitions) ([]byte, error) {
+	parsed, err := parseJSON(ir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse IR")
+	}
+	def, ok := parsed.(*jsonObject)
+	if !ok {
+		return nil, errors.Errorf("IR must be a JSON object")
+	}
+	for _, curr := range []struct {
+		key   string
+		names []QualifiedName
+		name  func(*jsonObject) interface{}
+	}{
+		{"types", defs.Types, func(o *jsonObject) interface{} {
+			if inner, ok := o.values[stringValue(o.values["type"])].(*jsonObject); ok {
+				return inner.values["typeName"]
+			}
+			return nil
+		}},
+		{"errors", defs.Errors, func(o *jsonObject) interface{} { return o.values["errorName"] }},
+		{"services", defs.Services, func(o *jsonObject) interface{} { return o.values["serviceName"] }},
+	} {
+		keep := make(map[QualifiedName]struct{})
+		for _, name := range curr.names {
+			keep[name] = struct{}{}
+		}
+		elems, _ := def.values[curr.key].([]interface{})
+		filtered := []interface{}{}
+		for _, elem := range elems {
+			elemObj, ok := elem.(*jsonObject)
+			if !ok {
+				return nil, errors.Errorf("%s of IR must be JSON objects", curr.key)
+			}
+			nameObj, ok := curr.name(elemObj).(*jsonObject)
+			if !ok {
+				return nil, errors.Errorf("failed to determine name of element of %s of IR", curr.key)
+			}
+			name := QualifiedName{Name: stringValue(nameObj.values["name"]), Package: stringValue(nameObj.values["package"])}
+			if _, ok := keep[name]; ok {
+				filtered = append(filtered, elem)
+			}
+		}
+		def.set(curr.key, filtered)
+	}
+	buf := &bytes.Buffer{}
+	writeJSON(buf, def)
+	return buf.Bytes(), nil
+}
+
+func stringValue(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
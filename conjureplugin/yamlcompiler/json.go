@@ -0,0 +1,198 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yamlcompiler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// jsonObject is a JSON object whose keys are written in the order in which they were added. Values are nil,
+// *jsonObject, []interface{}, string, int, bool or json.Number.
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newJSONObject() *jsonObject {
+	return &jsonObject{
+		values: make(map[string]interface{}),
+	}
+}
+
+// set sets the value for the provided key. Keys that are already set keep their position.
+func (o *jsonObject) set(key string, value interface{}) *jsonObject {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+	return o
+}
+
+// setOptional sets the value for the provided key only if the provided value is not the empty string, which matches the
+// manner in which the Conjure compiler omits absent optional values.
+func (o *jsonObject) setOptional(key, value string) *jsonObject {
+	if value == "" {
+		return o
+	}
+	return o.set(key, value)
+}
+
+// writeJSON writes the provided value in the format of the pretty printer used by the Conjure compiler: objects are
+// indented by 2 spaces per level, keys are separated from values by " : " and arrays are written inline.
+func writeJSON(w *bytes.Buffer, value interface{}) {
+	writeJSONValue(w, value, 0)
+}
+
+func writeJSONValue(w *bytes.Buffer, value interface{}, nesting int) {
+	switch v := value.(type) {
+	case nil:
+		w.WriteString("null")
+	case *jsonObject:
+		if len(v.keys) == 0 {
+			w.WriteString("{ }")
+			return
+		}
+		w.WriteString("{")
+		for i, k := range v.keys {
+			if i > 0 {
+				w.WriteString(",")
+			}
+			w.WriteString("\n")
+			w.WriteString(strings.Repeat("  ", nesting+1))
+			writeJSONString(w, k)
+			w.WriteString(" : ")
+			writeJSONValue(w, v.values[k], nesting+1)
+		}
+		w.WriteString("\n")
+		w.WriteString(strings.Repeat("  ", nesting))
+		w.WriteString("}")
+	case []interface{}:
+		w.WriteString("[")
+		for i, elem := range v {
+			if i > 0 {
+				w.WriteString(",")
+			}
+			w.WriteString(" ")
+			writeJSONValue(w, elem, nesting)
+		}
+		w.WriteString(" ]")
+	case string:
+		writeJSONString(w, v)
+	case int:
+		_, _ = fmt.Fprintf(w, "%d", v)
+	case bool:
+		_, _ = fmt.Fprintf(w, "%t", v)
+	case json.Number:
+		w.WriteString(v.String())
+	default:
+		panic(fmt.Sprintf("unsupported JSON value of type %T", value))
+	}
+}
+
+// writeJSONString writes the provided string as a JSON string. Only quotes, backslashes and control characters are
+// escaped.
+func writeJSONString(w *bytes.Buffer, s string) {
+	w.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			w.WriteString(`\"`)
+		case '\\':
+			w.WriteString(`\\`)
+		case '\b':
+			w.WriteString(`\b`)
+		case '\f':
+			w.WriteString(`\f`)
+		case '\n':
+			w.WriteString(`\n`)
+		case '\r':
+			w.WriteString(`\r`)
+		case '\t':
+			w.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				_, _ = fmt.Fprintf(w, `\u%04X`, r)
+				continue
+			}
+			w.WriteRune(r)
+		}
+	}
+	w.WriteByte('"')
+}
+
+// parseJSON parses the provided JSON into a value that preserves the order of the keys of objects.
+func parseJSON(in []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(in))
+	decoder.UseNumber()
+	value, err := parseJSONValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.Errorf("unexpected content after JSON value")
+	}
+	return value, nil
+}
+
+func parseJSONValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	switch v := token.(type) {
+	case json.Delim:
+		switch v {
+		case '{':
+			obj := newJSONObject()
+			for decoder.More() {
+				keyToken, err := decoder.Token()
+				if err != nil {
+					return nil, errors.WithStack(err)
+				}
+				value, err := parseJSONValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				obj.set(keyToken.(string), value)
+			}
+			if _, err := decoder.Token(); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			return obj, nil
+		case '[':
+			arr := []interface{}{}
+			for decoder.More() {
+				value, err := parseJSONValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, value)
+			}
+			if _, err := decoder.Token(); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			return arr, nil
+		}
+		return nil, errors.Errorf("unexpected delimiter %v", v)
+	default:
+		return v, nil
+	}
+}
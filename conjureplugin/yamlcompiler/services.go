@@ -0,0 +1,346 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yamlcompiler
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	httpMethods        = []string{"GET", "POST", "PUT", "DELETE"}
+	pathParamRegexp    = regexp.MustCompile(`\{([^}:*]+)[^}]*\}`)
+	endpointNameRegexp = regexp.MustCompile(`^[a-z][A-Za-z0-9]*$`)
+)
+
+// serviceDefinitions returns the IR for the services defined in the file.
+func (f *sourceFile) serviceDefinitions() ([]namedDefinition, error) {
+	entries, err := f.mapping(f.root, "services")
+	if err != nil {
+		return nil, err
+	}
+	var defs []namedDefinition
+	for _, entry := range entries {
+		if err := f.checkMapping(entry.value, "name", "package", "base-path", "default-auth", "docs", "endpoints"); err != nil {
+			return nil, err
+		}
+		if !typeNameRegexp.MatchString(entry.key.Value) {
+			return nil, f.errorf(entry.key, "service name %q must be UpperCamelCase", entry.key.Value)
+		}
+		pkg, err := f.optionalScalar(entry.value, "package")
+		if err != nil {
+			return nil, err
+		}
+		if !packageRegexp.MatchString(pkg) {
+			return nil, f.errorf(entry.key, "service %s must specify a package that consists of lowercase period-delimited segments", entry.key.Value)
+		}
+		name := typeName{name: entry.key.Value, pkg: pkg}
+		basePath, err := f.optionalScalar(entry.value, "base-path")
+		if err != nil {
+			return nil, err
+		}
+		if basePath != "" && !strings.HasPrefix(basePath, "/") {
+			return nil, f.errorf(entry.value, "base-path %q of service %s must begin with /", basePath, name.name)
+		}
+		defaultAuth, err := f.authType(entry.value, "default-auth")
+		if err != nil {
+			return nil, err
+		}
+		docs, err := f.optionalScalar(entry.value, "docs")
+		if err != nil {
+			return nil, err
+		}
+		endpointEntries, err := f.mapping(entry.value, "endpoints")
+		if err != nil {
+			return nil, err
+		}
+		endpoints := []interface{}{}
+		for _, endpointEntry := range endpointEntries {
+			endpoint, err := f.endpointDefinition(endpointEntry, basePath, defaultAuth)
+			if err != nil {
+				return nil, err
+			}
+			endpoints = append(endpoints, endpoint)
+		}
+		defs = append(defs, namedDefinition{
+			name: name,
+			value: newJSONObject().
+				set("serviceName", name.toJSON()).
+				set("endpoints", endpoints).
+				setOptional("docs", docs),
+		})
+	}
+	return defs, nil
+}
+
+// authType returns the IR for the auth type for the provided key of the provided node. Returns nil if the key is not
+// present or if its value is "none".
+func (f *sourceFile) authType(node *yaml.Node, key string) (*jsonObject, error) {
+	valueNode := mappingValue(node, key)
+	if valueNode == nil {
+		return nil, nil
+	}
+	auth, err := f.scalar(valueNode, key)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case auth == "none":
+		return nil, nil
+	case auth == "header":
+		return newJSONObject().set("type", "header").set("header", newJSONObject()), nil
+	case strings.HasPrefix(auth, "cookie:") && len(auth) > len("cookie:"):
+		return newJSONObject().
+			set("type", "cookie").
+			set("cookie", newJSONObject().set("cookieName", strings.TrimPrefix(auth, "cookie:"))), nil
+	default:
+		return nil, f.errorf(valueNode, "invalid auth %q: expected none, header or cookie:<name>", auth)
+	}
+}
+
+func (f *sourceFile) endpointDefinition(entry mappingEntry, basePath string, defaultAuth *jsonObject) (*jsonObject, error) {
+	endpointName := entry.key.Value
+	if !endpointNameRegexp.MatchString(endpointName) {
+		return nil, f.errorf(entry.key, "endpoint name %q must be lowerCamelCase", endpointName)
+	}
+	if err := f.checkMapping(entry.value, "http", "auth", "args", "returns", "docs", "deprecated", "markers", "tags"); err != nil {
+		return nil, err
+	}
+
+	httpNode := mappingValue(entry.value, "http")
+	if httpNode == nil {
+		return nil, f.errorf(entry.key, "endpoint %s must specify http", endpointName)
+	}
+	httpValue, err := f.scalar(httpNode, "http")
+	if err != nil {
+		return nil, err
+	}
+	httpParts := strings.Fields(httpValue)
+	if len(httpParts) != 2 || !contains(httpMethods, httpParts[0]) || !strings.HasPrefix(httpParts[1], "/") {
+		return nil, f.errorf(httpNode, "http %q of endpoint %s must be of the form \"<METHOD> /<path>\"", httpValue, endpointName)
+	}
+	httpMethod, endpointPath := httpParts[0], httpParts[1]
+	httpPath := strings.TrimSuffix(basePath, "/") + endpointPath
+
+	auth := defaultAuth
+	if hasKey(entry.value, "auth") {
+		if auth, err = f.authType(entry.value, "auth"); err != nil {
+			return nil, err
+		}
+	}
+
+	pathParams := make(map[string]struct{})
+	for _, match := range pathParamRegexp.FindAllStringSubmatch(endpointPath, -1) {
+		pathParams[match[1]] = struct{}{}
+	}
+	args, err := f.argumentDefinitions(entry.value, endpointName, httpMethod, pathParams)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := newJSONObject().
+		set("endpointName", endpointName).
+		set("httpMethod", httpMethod).
+		set("httpPath", httpPath)
+	if auth != nil {
+		endpoint.set("auth", auth)
+	}
+	endpoint.set("args", args)
+	if returnsNode := mappingValue(entry.value, "returns"); returnsNode != nil {
+		returns, err := f.resolveType(returnsNode)
+		if err != nil {
+			return nil, err
+		}
+		endpoint.set("returns", returns)
+	}
+	docs, err := f.optionalScalar(entry.value, "docs")
+	if err != nil {
+		return nil, err
+	}
+	deprecated, err := f.optionalScalar(entry.value, "deprecated")
+	if err != nil {
+		return nil, err
+	}
+	markers, err := f.markers(entry.value)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := f.tags(entry.value)
+	if err != nil {
+		return nil, err
+	}
+	return endpoint.
+		setOptional("docs", docs).
+		setOptional("deprecated", deprecated).
+		set("markers", markers).
+		set("tags", tags), nil
+}
+
+// argumentDefinitions returns the IR for the arguments of an endpoint. Arguments whose param-type is not specified are
+// path parameters if the path contains them and body parameters otherwise.
+func (f *sourceFile) argumentDefinitions(endpointNode *yaml.Node, endpointName, httpMethod string, pathParams map[string]struct{}) ([]interface{}, error) {
+	entries, err := f.mapping(endpointNode, "args")
+	if err != nil {
+		return nil, err
+	}
+	args := []interface{}{}
+	hasBody := false
+	for _, entry := range entries {
+		argName := entry.key.Value
+		typeNode := entry.value
+		paramType, paramID, docs := "auto", "", ""
+		markers, tags := []interface{}{}, []interface{}{}
+		if entry.value.Kind == yaml.MappingNode {
+			if err := f.checkMapping(entry.value, "type", "param-type", "param-id", "docs", "markers", "tags"); err != nil {
+				return nil, err
+			}
+			if typeNode = mappingValue(entry.value, "type"); typeNode == nil {
+				return nil, f.errorf(entry.value, "argument %s of endpoint %s must specify a type", argName, endpointName)
+			}
+			if value, err := f.optionalScalar(entry.value, "param-type"); err != nil {
+				return nil, err
+			} else if value != "" {
+				paramType = value
+			}
+			if paramID, err = f.optionalScalar(entry.value, "param-id"); err != nil {
+				return nil, err
+			}
+			if docs, err = f.optionalScalar(entry.value, "docs"); err != nil {
+				return nil, err
+			}
+			if markers, err = f.markers(entry.value); err != nil {
+				return nil, err
+			}
+			if tags, err = f.tags(entry.value); err != nil {
+				return nil, err
+			}
+		}
+		argType, err := f.resolveType(typeNode)
+		if err != nil {
+			return nil, err
+		}
+
+		if paramType == "auto" {
+			paramType = "body"
+			if _, ok := pathParams[argName]; ok {
+				paramType = "path"
+			}
+		}
+		paramTypeValue := newJSONObject().set("type", paramType)
+		switch paramType {
+		case "body":
+			if hasBody {
+				return nil, f.errorf(entry.key, "endpoint %s cannot have more than one body argument", endpointName)
+			}
+			if httpMethod == "GET" {
+				return nil, f.errorf(entry.key, "endpoint %s cannot have a body argument because it uses GET", endpointName)
+			}
+			hasBody = true
+			paramTypeValue.set("body", newJSONObject())
+		case "path":
+			if _, ok := pathParams[argName]; !ok {
+				return nil, f.errorf(entry.key, "path argument %s of endpoint %s does not occur in the path", argName, endpointName)
+			}
+			delete(pathParams, argName)
+			paramTypeValue.set("path", newJSONObject())
+		case "header":
+			if paramID == "" {
+				return nil, f.errorf(entry.key, "header argument %s of endpoint %s must specify param-id", argName, endpointName)
+			}
+			paramTypeValue.set("header", newJSONObject().set("paramId", paramID))
+		case "query":
+			if paramID == "" {
+				paramID = argName
+			}
+			paramTypeValue.set("query", newJSONObject().set("paramId", paramID))
+		default:
+			return nil, f.errorf(entry.value, "invalid param-type %q of argument %s of endpoint %s", paramType, argName, endpointName)
+		}
+
+		args = append(args, newJSONObject().
+			set("argName", argName).
+			set("type", argType).
+			set("paramType", paramTypeValue).
+			setOptional("docs", docs).
+			set("markers", markers).
+			set("tags", tags))
+	}
+	if len(pathParams) > 0 {
+		var missing []string
+		for pathParam := range pathParams {
+			missing = append(missing, pathParam)
+		}
+		sort.Strings(missing)
+		return nil, f.errorf(endpointNode, "path parameters of endpoint %s do not have corresponding arguments: %s", endpointName, strings.Join(missing, ", "))
+	}
+	return args, nil
+}
+
+// markers returns the IR for the "markers" of the provided node.
+func (f *sourceFile) markers(node *yaml.Node) ([]interface{}, error) {
+	markers := []interface{}{}
+	markersNode := mappingValue(node, "markers")
+	if markersNode == nil {
+		return markers, nil
+	}
+	if markersNode.Kind != yaml.SequenceNode {
+		return nil, f.errorf(markersNode, "expected markers to be a list")
+	}
+	for _, markerNode := range markersNode.Content {
+		marker, err := f.resolveType(markerNode)
+		if err != nil {
+			return nil, err
+		}
+		markers = append(markers, marker)
+	}
+	return markers, nil
+}
+
+// tags returns the distinct "tags" of the provided node in the order in which they are specified.
+func (f *sourceFile) tags(node *yaml.Node) ([]interface{}, error) {
+	tags := []interface{}{}
+	tagsNode := mappingValue(node, "tags")
+	if tagsNode == nil {
+		return tags, nil
+	}
+	if tagsNode.Kind != yaml.SequenceNode {
+		return nil, f.errorf(tagsNode, "expected tags to be a list")
+	}
+	seen := make(map[string]struct{})
+	for _, tagNode := range tagsNode.Content {
+		tag, err := f.scalar(tagNode, "tag")
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,498 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yamlcompiler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// typeName is the name and package of a Conjure type, error or service.
+type typeName struct {
+	name string
+	pkg  string
+}
+
+func (n typeName) String() string {
+	return n.pkg + "." + n.name
+}
+
+func (n typeName) toJSON() *jsonObject {
+	return newJSONObject().set("name", n.name).set("package", n.pkg)
+}
+
+// sourceFile is a single Conjure YAML file.
+type sourceFile struct {
+	path string
+	root *yaml.Node
+	// conjureImports maps the namespaces declared in "conjure-imports" to the imported files.
+	conjureImports map[string]*sourceFile
+	// externalImports maps the names of the types declared in "imports" to their IR.
+	externalImports map[string]interface{}
+	// localTypes maps the names of the types defined in the file to their qualified names.
+	localTypes map[string]typeName
+}
+
+type mappingEntry struct {
+	key   *yaml.Node
+	value *yaml.Node
+}
+
+func mappingEntries(node *yaml.Node) []mappingEntry {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	var entries []mappingEntry
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		entries = append(entries, mappingEntry{key: node.Content[i], value: node.Content[i+1]})
+	}
+	return entries
+}
+
+// mappingValue returns the value for the provided key of the provided mapping node. Returns nil if the node is not a
+// mapping, if it does not contain the key or if the value is null.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for _, entry := range mappingEntries(node) {
+		if entry.key.Value == key {
+			if isNull(entry.value) {
+				return nil
+			}
+			return entry.value
+		}
+	}
+	return nil
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+// errorf returns an error that identifies the location of the provided node.
+func (f *sourceFile) errorf(node *yaml.Node, format string, args ...interface{}) error {
+	return errors.Errorf("%s:%d:%d: %s", f.path, node.Line, node.Column, fmt.Sprintf(format, args...))
+}
+
+// checkMapping returns an error if the provided node is not a mapping or, if any keys are provided, if the mapping
+// contains a key that is not one of the provided keys.
+func (f *sourceFile) checkMapping(node *yaml.Node, allowedKeys ...string) error {
+	if node.Kind != yaml.MappingNode {
+		return f.errorf(node, "expected a mapping")
+	}
+	if len(allowedKeys) == 0 {
+		return nil
+	}
+	allowed := make(map[string]struct{})
+	for _, k := range allowedKeys {
+		allowed[k] = struct{}{}
+	}
+	for _, entry := range mappingEntries(node) {
+		if _, ok := allowed[entry.key.Value]; !ok {
+			return f.errorf(entry.key, "unknown key %q: expected one of %s", entry.key.Value, strings.Join(allowedKeys, ", "))
+		}
+	}
+	return nil
+}
+
+// scalar returns the value of the provided scalar node.
+func (f *sourceFile) scalar(node *yaml.Node, what string) (string, error) {
+	if node.Kind != yaml.ScalarNode || isNull(node) {
+		return "", f.errorf(node, "expected %s to be a string", what)
+	}
+	return node.Value, nil
+}
+
+// optionalScalar returns the value for the provided key of the provided mapping node, or the empty string if the key
+// is not present.
+func (f *sourceFile) optionalScalar(node *yaml.Node, key string) (string, error) {
+	valueNode := mappingValue(node, key)
+	if valueNode == nil {
+		return "", nil
+	}
+	return f.scalar(valueNode, key)
+}
+
+// mapping returns the entries for the provided key of the provided mapping node. A missing or null value has no
+// entries.
+func (f *sourceFile) mapping(node *yaml.Node, key string) ([]mappingEntry, error) {
+	valueNode := mappingValue(node, key)
+	if valueNode == nil {
+		return nil, nil
+	}
+	if err := f.checkMapping(valueNode); err != nil {
+		return nil, err
+	}
+	return mappingEntries(valueNode), nil
+}
+
+func (f *sourceFile) definitionsNode() *yaml.Node {
+	return mappingValue(mappingValue(f.root, "types"), "definitions")
+}
+
+var (
+	packageRegexp  = regexp.MustCompile(`^[a-z][a-z0-9]*(\.[a-z][a-z0-9]*)*$`)
+	typeNameRegexp = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+)
+
+// packageFor returns the package for a definition: the value of its "package" key if present and the default package
+// of the file otherwise.
+func (f *sourceFile) packageFor(defNode *yaml.Node, nameNode *yaml.Node) (string, error) {
+	pkg, err := f.optionalScalar(defNode, "package")
+	if err != nil {
+		return "", err
+	}
+	if pkg == "" {
+		pkg, err = f.optionalScalar(f.definitionsNode(), "default-package")
+		if err != nil {
+			return "", err
+		}
+	}
+	if pkg == "" {
+		return "", f.errorf(nameNode, "%s must specify a package because the file does not specify a default-package", nameNode.Value)
+	}
+	if !packageRegexp.MatchString(pkg) {
+		return "", f.errorf(nameNode, "package %q of %s must consist of lowercase period-delimited segments", pkg, nameNode.Value)
+	}
+	return pkg, nil
+}
+
+// collectLocalTypes records the qualified names of the types defined in the file.
+func (f *sourceFile) collectLocalTypes() error {
+	definitionsNode := f.definitionsNode()
+	if definitionsNode == nil {
+		return nil
+	}
+	if err := f.checkMapping(definitionsNode, "default-package", "objects", "errors"); err != nil {
+		return err
+	}
+	objects, err := f.mapping(definitionsNode, "objects")
+	if err != nil {
+		return err
+	}
+	for _, entry := range objects {
+		if !typeNameRegexp.MatchString(entry.key.Value) {
+			return f.errorf(entry.key, "type name %q must be UpperCamelCase", entry.key.Value)
+		}
+		if err := f.checkMapping(entry.value); err != nil {
+			return err
+		}
+		pkg, err := f.packageFor(entry.value, entry.key)
+		if err != nil {
+			return err
+		}
+		f.localTypes[entry.key.Value] = typeName{name: entry.key.Value, pkg: pkg}
+	}
+	return nil
+}
+
+// collectExternalImports records the IR of the types declared in the "imports" block of the file.
+func (f *sourceFile) collectExternalImports() error {
+	imports, err := f.mapping(mappingValue(f.root, "types"), "imports")
+	if err != nil {
+		return err
+	}
+	for _, entry := range imports {
+		if _, ok := f.localTypes[entry.key.Value]; ok {
+			return f.errorf(entry.key, "imported type %s is also defined in the file", entry.key.Value)
+		}
+		if err := f.checkMapping(entry.value, "base-type", "external"); err != nil {
+			return err
+		}
+		baseTypeNode := mappingValue(entry.value, "base-type")
+		if baseTypeNode == nil {
+			return f.errorf(entry.value, "imported type %s must specify base-type", entry.key.Value)
+		}
+		baseType, err := f.resolveType(baseTypeNode)
+		if err != nil {
+			return err
+		}
+		externalNode := mappingValue(entry.value, "external")
+		if externalNode == nil {
+			return f.errorf(entry.value, "imported type %s must specify external", entry.key.Value)
+		}
+		if err := f.checkMapping(externalNode, "java"); err != nil {
+			return err
+		}
+		javaName, err := f.optionalScalar(externalNode, "java")
+		if err != nil {
+			return err
+		}
+		if javaName == "" {
+			return f.errorf(externalNode, "imported type %s must specify a java type", entry.key.Value)
+		}
+		externalName := typeName{name: javaName}
+		if idx := strings.LastIndex(javaName, "."); idx != -1 {
+			externalName = typeName{name: javaName[idx+1:], pkg: javaName[:idx]}
+		}
+		f.externalImports[entry.key.Value] = newJSONObject().
+			set("type", "external").
+			set("external", newJSONObject().
+				set("externalReference", externalName.toJSON()).
+				set("fallback", baseType))
+	}
+	return nil
+}
+
+// typeDefinitions returns the IR for the types defined in the file.
+func (f *sourceFile) typeDefinitions() ([]namedDefinition, error) {
+	objects, err := f.mapping(f.definitionsNode(), "objects")
+	if err != nil {
+		return nil, err
+	}
+	var defs []namedDefinition
+	for _, entry := range objects {
+		name := f.localTypes[entry.key.Value]
+		def, err := f.typeDefinition(name, entry.key, entry.value)
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, namedDefinition{name: name, value: def})
+	}
+	return defs, nil
+}
+
+func (f *sourceFile) typeDefinition(name typeName, nameNode, defNode *yaml.Node) (*jsonObject, error) {
+	docs, err := f.optionalScalar(defNode, "docs")
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case mappingValue(defNode, "alias") != nil:
+		if err := f.checkMapping(defNode, "alias", "docs", "package"); err != nil {
+			return nil, err
+		}
+		aliasType, err := f.resolveType(mappingValue(defNode, "alias"))
+		if err != nil {
+			return nil, err
+		}
+		return newJSONObject().
+			set("type", "alias").
+			set("alias", newJSONObject().
+				set("typeName", name.toJSON()).
+				set("alias", aliasType).
+				setOptional("docs", docs)), nil
+	case mappingValue(defNode, "values") != nil:
+		if err := f.checkMapping(defNode, "values", "docs", "package"); err != nil {
+			return nil, err
+		}
+		values, err := f.enumValues(mappingValue(defNode, "values"))
+		if err != nil {
+			return nil, err
+		}
+		return newJSONObject().
+			set("type", "enum").
+			set("enum", newJSONObject().
+				set("typeName", name.toJSON()).
+				set("values", values).
+				setOptional("docs", docs)), nil
+	case hasKey(defNode, "union"):
+		if err := f.checkMapping(defNode, "union", "docs", "package"); err != nil {
+			return nil, err
+		}
+		fields, err := f.fieldDefinitions(defNode, "union")
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) == 0 {
+			return nil, f.errorf(nameNode, "union %s must have at least one member", name.name)
+		}
+		return newJSONObject().
+			set("type", "union").
+			set("union", newJSONObject().
+				set("typeName", name.toJSON()).
+				set("union", fields).
+				setOptional("docs", docs)), nil
+	case hasKey(defNode, "fields"):
+		if err := f.checkMapping(defNode, "fields", "docs", "package"); err != nil {
+			return nil, err
+		}
+		fields, err := f.fieldDefinitions(defNode, "fields")
+		if err != nil {
+			return nil, err
+		}
+		return newJSONObject().
+			set("type", "object").
+			set("object", newJSONObject().
+				set("typeName", name.toJSON()).
+				set("fields", fields).
+				setOptional("docs", docs)), nil
+	default:
+		return nil, f.errorf(nameNode, "type %s must specify one of alias, values, union or fields", name.name)
+	}
+}
+
+func hasKey(node *yaml.Node, key string) bool {
+	for _, entry := range mappingEntries(node) {
+		if entry.key.Value == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *sourceFile) enumValues(valuesNode *yaml.Node) ([]interface{}, error) {
+	if valuesNode.Kind != yaml.SequenceNode {
+		return nil, f.errorf(valuesNode, "expected enum values to be a list")
+	}
+	values := []interface{}{}
+	seen := make(map[string]struct{})
+	for _, valueNode := range valuesNode.Content {
+		value := newJSONObject()
+		var valueStr string
+		if valueNode.Kind == yaml.MappingNode {
+			if err := f.checkMapping(valueNode, "value", "docs", "deprecated"); err != nil {
+				return nil, err
+			}
+			var err error
+			if valueStr, err = f.optionalScalar(valueNode, "value"); err != nil {
+				return nil, err
+			}
+			docs, err := f.optionalScalar(valueNode, "docs")
+			if err != nil {
+				return nil, err
+			}
+			deprecated, err := f.optionalScalar(valueNode, "deprecated")
+			if err != nil {
+				return nil, err
+			}
+			value.set("value", valueStr).setOptional("docs", docs).setOptional("deprecated", deprecated)
+		} else {
+			var err error
+			if valueStr, err = f.scalar(valueNode, "enum value"); err != nil {
+				return nil, err
+			}
+			value.set("value", valueStr)
+		}
+		if !enumValueRegexp.MatchString(valueStr) {
+			return nil, f.errorf(valueNode, "enum value %q must be UPPER_SNAKE_CASE", valueStr)
+		}
+		if _, ok := seen[valueStr]; ok {
+			return nil, f.errorf(valueNode, "enum value %s is specified more than once", valueStr)
+		}
+		seen[valueStr] = struct{}{}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+var enumValueRegexp = regexp.MustCompile(`^[A-Z][A-Z0-9]*(_[A-Z0-9]+)*$`)
+
+// fieldDefinitions returns the IR for the fields in the mapping for the provided key of the provided node. Every field
+// is either a type or a mapping that specifies its type, docs and deprecation.
+func (f *sourceFile) fieldDefinitions(defNode *yaml.Node, key string) ([]interface{}, error) {
+	entries, err := f.mapping(defNode, key)
+	if err != nil {
+		return nil, err
+	}
+	fields := []interface{}{}
+	for _, entry := range entries {
+		field := newJSONObject().set("fieldName", entry.key.Value)
+		typeNode := entry.value
+		var docs, deprecated string
+		if entry.value.Kind == yaml.MappingNode {
+			if err := f.checkMapping(entry.value, "type", "docs", "deprecated"); err != nil {
+				return nil, err
+			}
+			if typeNode = mappingValue(entry.value, "type"); typeNode == nil {
+				return nil, f.errorf(entry.value, "field %s must specify a type", entry.key.Value)
+			}
+			if docs, err = f.optionalScalar(entry.value, "docs"); err != nil {
+				return nil, err
+			}
+			if deprecated, err = f.optionalScalar(entry.value, "deprecated"); err != nil {
+				return nil, err
+			}
+		}
+		fieldType, err := f.resolveType(typeNode)
+		if err != nil {
+			return nil, err
+		}
+		field.set("type", fieldType).setOptional("docs", docs).setOptional("deprecated", deprecated)
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+var errorCodes = map[string]struct{}{
+	"PERMISSION_DENIED":        {},
+	"INVALID_ARGUMENT":         {},
+	"NOT_FOUND":                {},
+	"CONFLICT":                 {},
+	"REQUEST_ENTITY_TOO_LARGE": {},
+	"FAILED_PRECONDITION":      {},
+	"INTERNAL":                 {},
+	"TIMEOUT":                  {},
+	"CUSTOM_CLIENT":            {},
+	"CUSTOM_SERVER":            {},
+}
+
+// errorDefinitions returns the IR for the errors defined in the file.
+func (f *sourceFile) errorDefinitions() ([]namedDefinition, error) {
+	entries, err := f.mapping(f.definitionsNode(), "errors")
+	if err != nil {
+		return nil, err
+	}
+	var defs []namedDefinition
+	for _, entry := range entries {
+		if err := f.checkMapping(entry.value, "namespace", "code", "package", "docs", "safe-args", "unsafe-args"); err != nil {
+			return nil, err
+		}
+		pkg, err := f.packageFor(entry.value, entry.key)
+		if err != nil {
+			return nil, err
+		}
+		name := typeName{name: entry.key.Value, pkg: pkg}
+		docs, err := f.optionalScalar(entry.value, "docs")
+		if err != nil {
+			return nil, err
+		}
+		namespace, err := f.optionalScalar(entry.value, "namespace")
+		if err != nil {
+			return nil, err
+		}
+		if namespace == "" {
+			return nil, f.errorf(entry.key, "error %s must specify a namespace", name.name)
+		}
+		code, err := f.optionalScalar(entry.value, "code")
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := errorCodes[code]; !ok {
+			return nil, f.errorf(entry.key, "error %s has invalid code %q", name.name, code)
+		}
+		safeArgs, err := f.fieldDefinitions(entry.value, "safe-args")
+		if err != nil {
+			return nil, err
+		}
+		unsafeArgs, err := f.fieldDefinitions(entry.value, "unsafe-args")
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, namedDefinition{
+			name: name,
+			value: newJSONObject().
+				set("errorName", name.toJSON()).
+				setOptional("docs", docs).
+				set("namespace", namespace).
+				set("code", code).
+				set("safeArgs", safeArgs).
+				set("unsafeArgs", unsafeArgs),
+		})
+	}
+	return defs, nil
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yamlcompiler
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var primitiveTypes = map[string]string{
+	"any":         "ANY",
+	"bearertoken": "BEARERTOKEN",
+	"binary":      "BINARY",
+	"boolean":     "BOOLEAN",
+	"datetime":    "DATETIME",
+	"double":      "DOUBLE",
+	"integer":     "INTEGER",
+	"rid":         "RID",
+	"safelong":    "SAFELONG",
+	"string":      "STRING",
+	"uuid":        "UUID",
+}
+
+// resolveType returns the IR for the type expression in the provided node.
+func (f *sourceFile) resolveType(node *yaml.Node) (interface{}, error) {
+	expr, err := f.scalar(node, "type")
+	if err != nil {
+		return nil, err
+	}
+	return f.resolveTypeExpr(node, expr)
+}
+
+// resolveTypeExpr returns the IR for the provided type expression. The node is used to report the location of errors.
+func (f *sourceFile) resolveTypeExpr(node *yaml.Node, expr string) (interface{}, error) {
+	expr = strings.TrimSpace(expr)
+	if openIdx := strings.Index(expr, "<"); openIdx != -1 {
+		if !strings.HasSuffix(expr, ">") {
+			return nil, f.errorf(node, "invalid type %q", expr)
+		}
+		container := strings.TrimSpace(expr[:openIdx])
+		args, ok := splitTypeArgs(expr[openIdx+1 : len(expr)-1])
+		if !ok {
+			return nil, f.errorf(node, "invalid type %q", expr)
+		}
+		var resolvedArgs []interface{}
+		for _, arg := range args {
+			resolvedArg, err := f.resolveTypeExpr(node, arg)
+			if err != nil {
+				return nil, err
+			}
+			resolvedArgs = append(resolvedArgs, resolvedArg)
+		}
+		switch container {
+		case "optional", "list", "set":
+			if len(resolvedArgs) != 1 {
+				return nil, f.errorf(node, "type %q must have exactly one type parameter", expr)
+			}
+			return newJSONObject().
+				set("type", container).
+				set(container, newJSONObject().set("itemType", resolvedArgs[0])), nil
+		case "map":
+			if len(resolvedArgs) != 2 {
+				return nil, f.errorf(node, "type %q must have exactly two type parameters", expr)
+			}
+			return newJSONObject().
+				set("type", "map").
+				set("map", newJSONObject().set("keyType", resolvedArgs[0]).set("valueType", resolvedArgs[1])), nil
+		default:
+			return nil, f.errorf(node, "unknown container type %q in type %q", container, expr)
+		}
+	}
+
+	if primitive, ok := primitiveTypes[expr]; ok {
+		return newJSONObject().set("type", "primitive").set("primitive", primitive), nil
+	}
+	if dotIdx := strings.Index(expr, "."); dotIdx != -1 {
+		namespace, name := expr[:dotIdx], expr[dotIdx+1:]
+		imported, ok := f.conjureImports[namespace]
+		if !ok {
+			return nil, f.errorf(node, "unknown conjure import namespace %q in type %q", namespace, expr)
+		}
+		if importedName, ok := imported.localTypes[name]; ok {
+			return referenceType(importedName), nil
+		}
+		if external, ok := imported.externalImports[name]; ok {
+			return external, nil
+		}
+		return nil, f.errorf(node, "type %s is not defined in %s", name, imported.path)
+	}
+	if localName, ok := f.localTypes[expr]; ok {
+		return referenceType(localName), nil
+	}
+	if external, ok := f.externalImports[expr]; ok {
+		return external, nil
+	}
+	return nil, f.errorf(node, "unknown type %q", expr)
+}
+
+func referenceType(name typeName) *jsonObject {
+	return newJSONObject().set("type", "reference").set("reference", name.toJSON())
+}
+
+// splitTypeArgs splits the provided comma-separated type arguments at the top level of nesting. Returns false if the
+// brackets in the input are unbalanced.
+func splitTypeArgs(in string) ([]string, bool) {
+	var args []string
+	depth, start := 0, 0
+	for i, r := range in {
+		switch r {
+		case '<':
+			depth++
+		case '>':
+			depth--
+			if depth < 0 {
+				return nil, false
+			}
+		case ',':
+			if depth == 0 {
+				args = append(args, in[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, false
+	}
+	return append(args, in[start:]), true
+}
@@ -339,7 +339,8 @@ projects:
 	_, err = pluginapitester.RunPlugin(pluginapitester.NewPluginProvider(pluginPath), nil, "conjure", []string{"--verify"}, projectDir, false, outputBuf)
 	assert.Error(t, err, "modified file did not trigger verify fail")
 	stdout := outputBuf.String()
-	assert.True(t, strings.Contains(stdout, structsFile+": checksum changed"), "Unexpected standard out: %s", stdout)
+	assert.True(t, strings.Contains(stdout, structsFile+"\ton disk\n"), "Unexpected standard out: %s", stdout)
+	assert.True(t, strings.Contains(stdout, "\n    -package api\n"), "Unexpected standard out: %s", stdout)
 }
 
 func TestConjurePluginPublish(t *testing.T) {
@@ -0,0 +1,183 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureircli
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CompileError is the error returned when the Conjure CLI fails to compile its input. The diagnostics are parsed from
+// the output of the CLI, which reports failures as Java exceptions. Callers can use errors.As to inspect the error.
+type CompileError struct {
+	// InputPath is the path of the input that was compiled.
+	InputPath string
+	// Args are the arguments of the command that was run, including the path of the CLI.
+	Args []string
+	// Output is the combined output of the CLI.
+	Output string
+	// Diagnostics are the problems that were parsed from the output. Empty if the output could not be parsed.
+	Diagnostics []Diagnostic
+	// Err is the error returned by running the CLI.
+	Err error
+}
+
+func (e *CompileError) Error() string {
+	if len(e.Diagnostics) == 0 {
+		return fmt.Sprintf("failed to execute %v\nOutput:\n%s: %v", e.Args, e.Output, e.Err)
+	}
+	parts := []string{fmt.Sprintf("Conjure CLI failed to compile %s:", e.InputPath)}
+	for _, diagnostic := range e.Diagnostics {
+		parts = append(parts, "  "+diagnostic.String())
+	}
+	return strings.Join(parts, "\n")
+}
+
+func (e *CompileError) Unwrap() error {
+	return e.Err
+}
+
+// Diagnostic is a problem reported by the Conjure CLI. File, Line and Column are the zero value if the CLI did not
+// report them.
+type Diagnostic struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+// String returns the diagnostic in the form "<file>:<line>:<column>: <message>", omitting the parts of the location
+// that are not known.
+func (d Diagnostic) String() string {
+	location := d.File
+	if d.Line > 0 {
+		location += fmt.Sprintf(":%d", d.Line)
+		if d.Column > 0 {
+			location += fmt.Sprintf(":%d", d.Column)
+		}
+	}
+	location = strings.TrimPrefix(location, ":")
+	if location == "" {
+		return d.Message
+	}
+	return location + ": " + d.Message
+}
+
+var (
+	exceptionRegexp = regexp.MustCompile(`^(?:Exception in thread "[^"]*" )?(Caused by: )?((?:[a-zA-Z_$][\w$]*\.)+[A-Z][\w$]*(?:Exception|Error|Throwable))(?::\s?(.*))?$`)
+	// stackFrameRegexp matches the lines of a stack trace that do not contain messages. The location that Jackson
+	// appends to messages also begins with "at", so frames are matched by the parenthesis that follows the method.
+	stackFrameRegexp = regexp.MustCompile(`^\s+(?:at [\w$.<>/]+\(|\.\.\. \d+ (?:more|common frames omitted)|Suppressed: )`)
+	// jacksonLocationRegexp matches the location that Jackson appends to the messages of its exceptions, for example
+	// "at [Source: (File); line: 6, column: 18]".
+	jacksonLocationRegexp = regexp.MustCompile(`\s*at \[Source: ([^;\]]*); line: (\d+), column: (\d+)\].*$`)
+	// yamlLocationRegexp matches the location that SnakeYAML includes in the messages of its exceptions, for example
+	// "in 'reader', line 6, column 18:".
+	yamlLocationRegexp   = regexp.MustCompile(`^\s*in '[^']*', line (\d+), column (\d+):?\s*$`)
+	referenceChainRegexp = regexp.MustCompile(`\s*\(through reference chain: .*\)$`)
+	yamlFileRegexp       = regexp.MustCompile(`[^\s"'(:;]*\.ya?ml\b`)
+)
+
+// javaException is an exception in a Java stack trace.
+type javaException struct {
+	class   string
+	message []string
+}
+
+// parseDiagnostics returns the diagnostics for the Java stack traces in the provided output of the Conjure CLI. Every
+// stack trace results in a single diagnostic: the message is the message of the innermost cause, the file is the first
+// Conjure YAML file that is named by any exception in the chain and the line and column are those of the innermost
+// exception that reports them. Returns nil if the output does not contain a stack trace.
+func parseDiagnostics(output string) []Diagnostic {
+	var chains [][]*javaException
+	var current *javaException
+	for _, line := range strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n") {
+		if stackFrameRegexp.MatchString(line) {
+			current = nil
+			continue
+		}
+		if match := exceptionRegexp.FindStringSubmatch(line); match != nil {
+			current = &javaException{class: match[2]}
+			if match[3] != "" {
+				current.message = append(current.message, match[3])
+			}
+			if match[1] != "" && len(chains) > 0 {
+				chains[len(chains)-1] = append(chains[len(chains)-1], current)
+			} else {
+				chains = append(chains, []*javaException{current})
+			}
+			continue
+		}
+		if current != nil {
+			current.message = append(current.message, line)
+		}
+	}
+
+	var diagnostics []Diagnostic
+	for _, chain := range chains {
+		diagnostics = append(diagnostics, chainDiagnostic(chain))
+	}
+	return diagnostics
+}
+
+func chainDiagnostic(chain []*javaException) Diagnostic {
+	var diagnostic Diagnostic
+	for _, exception := range chain {
+		for _, line := range exception.message {
+			if diagnostic.File != "" {
+				break
+			}
+			if match := jacksonLocationRegexp.FindStringSubmatch(line); match != nil {
+				if file := yamlFileRegexp.FindString(match[1]); file != "" {
+					diagnostic.File = file
+					break
+				}
+				line = strings.TrimSuffix(line, match[0])
+			}
+			diagnostic.File = yamlFileRegexp.FindString(line)
+		}
+	}
+	for i := len(chain) - 1; i >= 0 && diagnostic.Line == 0; i-- {
+		for _, line := range chain[i].message {
+			if match := jacksonLocationRegexp.FindStringSubmatch(line); match != nil {
+				diagnostic.Line, _ = strconv.Atoi(match[2])
+				diagnostic.Column, _ = strconv.Atoi(match[3])
+				break
+			}
+			if match := yamlLocationRegexp.FindStringSubmatch(line); match != nil {
+				diagnostic.Line, _ = strconv.Atoi(match[1])
+				diagnostic.Column, _ = strconv.Atoi(match[2])
+				break
+			}
+		}
+	}
+	for i := len(chain) - 1; i >= 0 && diagnostic.Message == ""; i-- {
+		for _, line := range chain[i].message {
+			line = jacksonLocationRegexp.ReplaceAllString(line, "")
+			line = strings.TrimSpace(referenceChainRegexp.ReplaceAllString(line, ""))
+			if line != "" && !yamlLocationRegexp.MatchString(line) {
+				diagnostic.Message = line
+				break
+			}
+		}
+	}
+	if diagnostic.Message == "" {
+		innermost := chain[len(chain)-1].class
+		diagnostic.Message = innermost[strings.LastIndex(innermost, ".")+1:]
+	}
+	return diagnostic
+}
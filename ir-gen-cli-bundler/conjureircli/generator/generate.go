@@ -17,20 +17,30 @@
 package main
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path"
 	"regexp"
+	"sort"
 
 	"github.com/go-bindata/go-bindata"
 )
 
 const conjureVersion = "4.14.1"
 
+// checksumsAssetName must match the name of the checksums asset that is read by conjureircli.
+const checksumsAssetName = "conjure.tgz.sha256"
+
 func main() {
 	versionFilePath := "../internal/version.go"
+	bindataFilePath := "../internal/bindata.go"
 	newVersionFileContent := fmt.Sprintf(`// This is a generated file: do not edit by hand.
 // To update this file, run the generator in conjureircli/generator.
 package conjureircli_internal
@@ -38,20 +48,33 @@ package conjureircli_internal
 const Version = "%s"
 `, conjureVersion)
 
-	// version file exists and is in desired state: assume that all generated content is in desired state
+	// version file exists and is in desired state and the bundled data includes the checksums: assume that all
+	// generated content is in desired state
 	if currVersionFileContent, err := ioutil.ReadFile(versionFilePath); err == nil && string(currVersionFileContent) == newVersionFileContent {
-		return
+		if bindataContent, err := ioutil.ReadFile(bindataFilePath); err == nil && bytes.Contains(bindataContent, []byte(checksumsAssetName)) {
+			return
+		}
 	}
 
 	conjureTgzPath := "conjure.tgz"
 	defer func() {
 		_ = os.Remove(conjureTgzPath)
+		_ = os.Remove(checksumsAssetName)
 	}()
 
 	if err := downloadFile(conjureTgzPath, fmt.Sprintf("https://palantir.bintray.com/releases/com/palantir/conjure/conjure/%s/conjure-%s.tgz", conjureVersion, conjureVersion)); err != nil {
 		panic(err)
 	}
 
+	// record the checksums of the files in the archive so that the unpacked archive can be verified
+	checksumsContent, err := archiveChecksums(conjureTgzPath)
+	if err != nil {
+		panic(err)
+	}
+	if err := ioutil.WriteFile(checksumsAssetName, checksumsContent, 0644); err != nil {
+		panic(err)
+	}
+
 	if err := bindata.Translate(&bindata.Config{
 		Input: []bindata.InputConfig{
 			{
@@ -62,7 +85,7 @@ const Version = "%s"
 			regexp.MustCompile(`.*\.go`),
 		},
 		NoCompress: true,
-		Output:     "../internal/bindata.go",
+		Output:     bindataFilePath,
 		Package:    "conjureircli_internal",
 	}); err != nil {
 		panic(err)
@@ -73,6 +96,52 @@ const Version = "%s"
 	}
 }
 
+// archiveChecksums returns the SHA-256 checksums of the regular files in the tgz archive at the provided path. Every
+// line is of the form "<hex digest>  <path>", and the lines are sorted by path.
+func archiveChecksums(tgzPath string) ([]byte, error) {
+	f, err := os.Open(tgzPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	gzipReader, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	checksums := make(map[string]string)
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		h := sha256.New()
+		if _, err := io.Copy(h, tarReader); err != nil {
+			return nil, err
+		}
+		checksums[path.Clean(header.Name)] = fmt.Sprintf("%x", h.Sum(nil))
+	}
+
+	var paths []string
+	for k := range checksums {
+		paths = append(paths, k)
+	}
+	sort.Strings(paths)
+	buf := &bytes.Buffer{}
+	for _, p := range paths {
+		_, _ = fmt.Fprintf(buf, "%s  %s\n", checksums[p], p)
+	}
+	return buf.Bytes(), nil
+}
+
 func downloadFile(filepath string, url string) error {
 	out, err := os.Create(filepath)
 	if err != nil {
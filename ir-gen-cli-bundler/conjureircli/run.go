@@ -15,15 +15,17 @@
 package conjureircli
 
 import (
-	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
+	"regexp"
 	"runtime"
+	"strings"
+	"sync"
 
-	"github.com/mholt/archiver"
 	conjureircli_internal "github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli/internal"
 	"github.com/palantir/pkg/safejson"
 	"github.com/pkg/errors"
@@ -84,6 +86,10 @@ func Run(inPath, outPath string) error {
 
 type runArgs struct {
 	extensionsContent []byte
+	javaHome          string
+	jvmOptions        []string
+	cliPath           string
+	cliVersion        string
 }
 
 type Param interface {
@@ -111,24 +117,57 @@ func ExtensionsParam(extensionsContent map[string]interface{}) (Param, error) {
 	}), nil
 }
 
+// JavaHomeParam returns a parameter that configures the CLI to run using the Java runtime in the provided Java home
+// directory. If the directory is empty, the Java runtime is determined by the environment.
+func JavaHomeParam(javaHome string) Param {
+	return paramFn(func(r *runArgs) {
+		r.javaHome = javaHome
+	})
+}
+
+// JVMOptionsParam returns a parameter that configures the options that are provided to the JVM that runs the CLI.
+func JVMOptionsParam(jvmOptions ...string) Param {
+	return paramFn(func(r *runArgs) {
+		r.jvmOptions = jvmOptions
+	})
+}
+
+// CLIPathParam returns a parameter that configures the Conjure CLI executable at the provided path to be run instead of
+// the bundled CLI. If the path is empty, the bundled CLI is run.
+func CLIPathParam(cliPath string) Param {
+	return paramFn(func(r *runArgs) {
+		r.cliPath = cliPath
+	})
+}
+
+// CLIVersionParam returns a parameter that requires the Conjure CLI that is run to have the provided version. If the
+// bundled CLI is used, the provided version must be the version of the bundled CLI.
+func CLIVersionParam(version string) Param {
+	return paramFn(func(r *runArgs) {
+		r.cliVersion = version
+	})
+}
+
 // RunWithParams invokes the "compile" operation on the Conjure CLI with the provided inPath and outPath as arguments.
-// Any arguments or configuration supplied by the provided params are also applied.
+// Any arguments or configuration supplied by the provided params are also applied. If the CLI fails, the returned error
+// is a *CompileError that contains the diagnostics reported by the CLI.
 func RunWithParams(inPath, outPath string, params ...Param) error {
-	cliPath, err := cliCmdPath()
+	runArgCollector := newRunArgs(params...)
+	env, err := cliEnv(runArgCollector)
 	if err != nil {
 		return err
 	}
-	if err := ensureCLIExists(cliPath); err != nil {
+	if _, err := checkedCLIVersion(runArgCollector, env); err != nil {
 		return err
 	}
-
-	// apply provided params
-	var runArgCollector runArgs
-	for _, param := range params {
-		if param == nil {
-			continue
+	cliPath := runArgCollector.cliPath
+	if cliPath == "" {
+		if cliPath, err = cliCmdPath(); err != nil {
+			return err
+		}
+		if err := ensureCLIExists(cliPath); err != nil {
+			return err
 		}
-		param.apply(&runArgCollector)
 	}
 
 	// invoke the "compile" command
@@ -143,12 +182,124 @@ func RunWithParams(inPath, outPath string, params ...Param) error {
 	args = append(args, inPath, outPath)
 
 	cmd := exec.Command(cliPath, args...)
+	cmd.Env = env
 	if output, err := cmd.CombinedOutput(); err != nil {
-		return errors.Wrapf(err, "failed to execute %v\nOutput:\n%s", cmd.Args, string(output))
+		return errors.WithStack(&CompileError{
+			InputPath:   inPath,
+			Args:        cmd.Args,
+			Output:      string(output),
+			Diagnostics: parseDiagnostics(string(output)),
+			Err:         err,
+		})
 	}
 	return nil
 }
 
+// CompilationKey returns a string that identifies the configuration of the CLI that is run with the provided
+// parameters: the version of the bundled CLI or the path, required version and SHA-256 digest of the content of an
+// external CLI, and the extensions. Running the CLI with parameters that have the same key on the same input produces
+// the same IR, so the key can be used to cache the output of the CLI. The CLI is never run to compute the key: an
+// external CLI that is replaced at the same path (for example, by an upgrade) has a different digest and therefore a
+// different key.
+func CompilationKey(params ...Param) (string, error) {
+	runArgCollector := newRunArgs(params...)
+	cli := conjureircli_internal.Version
+	if runArgCollector.cliPath == "" {
+		if runArgCollector.cliVersion != "" && runArgCollector.cliVersion != conjureircli_internal.Version {
+			return "", errors.Errorf("bundled Conjure CLI has version %s, but version %s is required", conjureircli_internal.Version, runArgCollector.cliVersion)
+		}
+	} else {
+		if err := checkCliExists(runArgCollector.cliPath); err != nil {
+			return "", errors.Wrapf(err, "invalid Conjure CLI %s", runArgCollector.cliPath)
+		}
+		digest, err := fileSHA256(runArgCollector.cliPath)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to compute digest of Conjure CLI %s", runArgCollector.cliPath)
+		}
+		cli = fmt.Sprintf("%s@%s sha256:%s", runArgCollector.cliPath, runArgCollector.cliVersion, digest)
+	}
+	return fmt.Sprintf("conjure %s\x00extensions %s", cli, runArgCollector.extensionsContent), nil
+}
+
+func newRunArgs(params ...Param) runArgs {
+	var runArgCollector runArgs
+	for _, param := range params {
+		if param == nil {
+			continue
+		}
+		param.apply(&runArgCollector)
+	}
+	return runArgCollector
+}
+
+// cliEnv returns the environment in which the CLI is run. The environment of the current process is used with the Java
+// home and JVM options set as specified by the provided arguments. The JVM options are provided using the CONJURE_OPTS
+// environment variable, which the start script of the CLI passes to the JVM.
+func cliEnv(r runArgs) ([]string, error) {
+	env := os.Environ()
+	if r.javaHome != "" {
+		if err := checkCliExists(filepath.Join(r.javaHome, "bin", "java")); err != nil {
+			return nil, errors.Wrapf(err, "Java home %s does not contain bin/java", r.javaHome)
+		}
+		env = append(env, "JAVA_HOME="+r.javaHome)
+	}
+	if len(r.jvmOptions) > 0 {
+		env = append(env, "CONJURE_OPTS="+strings.Join(r.jvmOptions, " "))
+	}
+	return env, nil
+}
+
+var (
+	cliVersionRegexp = regexp.MustCompile(`[0-9]+\.[0-9]+\.[0-9]+[0-9A-Za-z.+-]*`)
+	// cliVersions caches the version reported by each external CLI that has been run by this process, keyed by the
+	// path of the CLI and its environment.
+	cliVersions sync.Map
+)
+
+// checkedCLIVersion returns the version of the CLI that is run with the provided arguments and verifies that it matches
+// the required version of the arguments, if any. The version of the bundled CLI is known, while an external CLI is run
+// with the "--version" flag to determine its version.
+func checkedCLIVersion(r runArgs, env []string) (string, error) {
+	if r.cliPath == "" {
+		if r.cliVersion != "" && r.cliVersion != conjureircli_internal.Version {
+			return "", errors.Errorf("bundled Conjure CLI has version %s, but version %s is required", conjureircli_internal.Version, r.cliVersion)
+		}
+		return conjureircli_internal.Version, nil
+	}
+	if err := checkCliExists(r.cliPath); err != nil {
+		return "", errors.Wrapf(err, "invalid Conjure CLI %s", r.cliPath)
+	}
+	version, err := externalCLIVersion(r.cliPath, env)
+	if err != nil {
+		return "", err
+	}
+	if r.cliVersion != "" && version != r.cliVersion {
+		return "", errors.Errorf("Conjure CLI %s has version %s, but version %s is required", r.cliPath, version, r.cliVersion)
+	}
+	return version, nil
+}
+
+// externalCLIVersion runs the CLI at the provided path with the "--version" flag and returns the version that it
+// reports.
+func externalCLIVersion(cliPath string, env []string) (string, error) {
+	key := cliPath + "\x00" + strings.Join(env, "\x00")
+	if version, ok := cliVersions.Load(key); ok {
+		return version.(string), nil
+	}
+	cmd := exec.Command(cliPath, "--version")
+	cmd.Env = env
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to determine version of Conjure CLI %s\nOutput:\n%s", cliPath, string(output))
+	}
+	version := cliVersionRegexp.FindString(string(output))
+	if version == "" {
+		return "", errors.Errorf("failed to determine version of Conjure CLI %s: output of --version did not contain a version\nOutput:\n%s", cliPath, string(output))
+	}
+	cliVersions.Store(key, version)
+	return version, nil
+}
+
 // cliUnpackDir is the directory into which the tarball is unpacked
 var cliUnpackDir = path.Join(os.TempDir(), "_conjureircli")
 
@@ -165,30 +316,28 @@ func cliCmdPath() (string, error) {
 	}
 }
 
-// ensureCLIExists installs the conjure compiler if it does not already exist or it appears malformed.
+// ensureCLIExists installs the conjure compiler if it has not already been unpacked and verified.
 func ensureCLIExists(cliPath string) error {
-	if checkCliExists(cliPath) == nil {
+	if isVerifiedDir(cliArchiveDir) && checkCliExists(cliPath) == nil {
 		// destination already exists
 		return nil
 	}
 
-	// destination does not exist or is malformed, remove the archive dir just in case of a previous bad install
-	if err := os.RemoveAll(cliArchiveDir); err != nil {
-		return errors.Wrap(err, "failed to remove destination dir before unpacking cli archive")
-	}
-
-	// expand asset into destination
 	tgzBytes, err := conjureircli_internal.Asset("conjure.tgz")
 	if err != nil {
 		return errors.WithStack(err)
 	}
-	if err := archiver.TarGz.Read(bytes.NewReader(tgzBytes), cliUnpackDir); err != nil {
-		return errors.WithStack(err)
+	checksums, err := bundledChecksums(tgzBytes, conjureircli_internal.Asset)
+	if err != nil {
+		return err
+	}
+	if err := unpackCLI(cliUnpackDir, filepath.Base(cliArchiveDir), tgzBytes, checksums); err != nil {
+		return err
 	}
 
 	// check that we can now find the cli
 	if err := checkCliExists(cliPath); err != nil {
-		return errors.Wrap(err, "failed to stat cli file after unpacking; please comment on godel-conjure-plugin#84 and retry")
+		return errors.Wrap(err, "failed to stat cli file after unpacking")
 	}
 
 	return nil
@@ -15,6 +15,12 @@
 package conjureircli_test
 
 import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/palantir/godel-conjure-plugin/v6/ir-gen-cli-bundler/conjureircli"
@@ -123,3 +129,140 @@ func mustExtensionsParam(in map[string]interface{}) conjureircli.Param {
 	}
 	return param
 }
+
+func TestRunWithExternalCLI(t *testing.T) {
+	dir := t.TempDir()
+	javaHome := filepath.Join(dir, "jdk")
+	require.NoError(t, os.MkdirAll(filepath.Join(javaHome, "bin"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(javaHome, "bin", "java"), []byte("#!/bin/sh\n"), 0755))
+
+	// the fake CLI reports its version and writes its environment and arguments as the output of "compile"
+	cliPath := filepath.Join(dir, "conjure")
+	require.NoError(t, ioutil.WriteFile(cliPath, []byte(`#!/bin/sh
+if [ "$1" = "--version" ]; then
+  echo "conjure 4.40.0"
+  exit 0
+fi
+for last; do true; done
+printf '%s|%s|%s' "$JAVA_HOME" "$CONJURE_OPTS" "$2" > "$last"
+`), 0755))
+	inPath := filepath.Join(dir, "in.yml")
+	require.NoError(t, ioutil.WriteFile(inPath, []byte("types: {}\n"), 0644))
+
+	got, err := conjureircli.InputPathToIRWithParams(inPath,
+		conjureircli.CLIPathParam(cliPath),
+		conjureircli.CLIVersionParam("4.40.0"),
+		conjureircli.JavaHomeParam(javaHome),
+		conjureircli.JVMOptionsParam("-Xmx512m", "-Dfoo=bar"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%s|-Xmx512m -Dfoo=bar|%s", javaHome, inPath), string(got))
+
+	_, err = conjureircli.InputPathToIRWithParams(inPath, conjureircli.CLIPathParam(cliPath), conjureircli.CLIVersionParam("4.41.0"))
+	assert.EqualError(t, err, fmt.Sprintf("Conjure CLI %s has version 4.40.0, but version 4.41.0 is required", cliPath))
+
+	_, err = conjureircli.InputPathToIRWithParams(inPath, conjureircli.CLIVersionParam("4.41.0"))
+	assert.EqualError(t, err, "bundled Conjure CLI has version 4.14.1, but version 4.41.0 is required")
+
+	_, err = conjureircli.InputPathToIRWithParams(inPath, conjureircli.CLIPathParam(cliPath), conjureircli.JavaHomeParam(dir))
+	assert.EqualError(t, err, fmt.Sprintf("Java home %s does not contain bin/java: stat %s: no such file or directory", dir, filepath.Join(dir, "bin", "java")))
+}
+
+func TestCompilationKey(t *testing.T) {
+	key, err := conjureircli.CompilationKey()
+	require.NoError(t, err)
+	assert.Equal(t, "conjure 4.14.1\x00extensions ", key)
+
+	extensionsParam, err := conjureircli.ExtensionsParam(map[string]interface{}{
+		"foo": "bar",
+	})
+	require.NoError(t, err)
+	key, err = conjureircli.CompilationKey(extensionsParam)
+	require.NoError(t, err)
+	assert.Equal(t, "conjure 4.14.1\x00extensions {\"foo\":\"bar\"}", key)
+
+	_, err = conjureircli.CompilationKey(conjureircli.CLIVersionParam("4.41.0"))
+	assert.EqualError(t, err, "bundled Conjure CLI has version 4.14.1, but version 4.41.0 is required")
+
+	// the key of an external CLI is computed without running the CLI and changes when the CLI at the path is replaced
+	cliPath := filepath.Join(t.TempDir(), "conjure")
+	require.NoError(t, ioutil.WriteFile(cliPath, []byte("#!/bin/sh\nexit 1\n"), 0755))
+	key, err = conjureircli.CompilationKey(conjureircli.CLIPathParam(cliPath), conjureircli.CLIVersionParam("4.40.0"))
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("conjure %s@4.40.0 sha256:%x\x00extensions ", cliPath, sha256.Sum256([]byte("#!/bin/sh\nexit 1\n"))), key)
+
+	require.NoError(t, ioutil.WriteFile(cliPath, []byte("#!/bin/sh\nexit 2\n"), 0755))
+	replacedKey, err := conjureircli.CompilationKey(conjureircli.CLIPathParam(cliPath), conjureircli.CLIVersionParam("4.40.0"))
+	require.NoError(t, err)
+	assert.NotEqual(t, key, replacedKey)
+}
+
+func TestRunCompileError(t *testing.T) {
+	dir := t.TempDir()
+	inPath := filepath.Join(dir, "api.yml")
+	require.NoError(t, ioutil.WriteFile(inPath, []byte("types: {}\n"), 0644))
+
+	for i, tc := range []struct {
+		output          string
+		wantDiagnostics []conjureircli.Diagnostic
+		wantErr         string
+	}{
+		{
+			output: `Exception in thread "main" com.palantir.conjure.exceptions.ConjureRuntimeException: Error while parsing ` + inPath + `:
+Unrecognized field "fieldz" (class com.palantir.conjure.parser.types.complex.ObjectTypeDefinition), not marked as ignorable (2 known properties: "docs", "fields"])
+ at [Source: (File); line: 6, column: 18] (through reference chain: com.palantir.conjure.parser.ConjureDefinition["types"])
+	at com.palantir.conjure.parser.ConjureParser$RecursiveParser.parseInternal(ConjureParser.java:156)
+	at com.palantir.conjure.cli.ConjureCli.main(ConjureCli.java:40)
+Caused by: com.fasterxml.jackson.databind.exc.UnrecognizedPropertyException: Unrecognized field "fieldz" (class com.palantir.conjure.parser.types.complex.ObjectTypeDefinition), not marked as ignorable (2 known properties: "docs", "fields"])
+ at [Source: (File); line: 6, column: 18] (through reference chain: com.palantir.conjure.parser.ConjureDefinition["types"])
+	at com.fasterxml.jackson.databind.exc.UnrecognizedPropertyException.from(UnrecognizedPropertyException.java:61)
+	... 12 more
+`,
+			wantDiagnostics: []conjureircli.Diagnostic{{
+				File:    inPath,
+				Line:    6,
+				Column:  18,
+				Message: `Unrecognized field "fieldz" (class com.palantir.conjure.parser.types.complex.ObjectTypeDefinition), not marked as ignorable (2 known properties: "docs", "fields"])`,
+			}},
+			wantErr: fmt.Sprintf(`Conjure CLI failed to compile %s:
+  %s:6:18: Unrecognized field "fieldz" (class com.palantir.conjure.parser.types.complex.ObjectTypeDefinition), not marked as ignorable (2 known properties: "docs", "fields"])`, inPath, inPath),
+		},
+		{
+			output: `Exception in thread "main" java.lang.IllegalStateException: Unknown LocalReferenceType: TypeName{name=Foo, package=com.palantir.test}
+	at com.palantir.conjure.defs.ConjureParserUtils.parseConjureDef(ConjureParserUtils.java:351)
+`,
+			wantDiagnostics: []conjureircli.Diagnostic{{
+				Message: "Unknown LocalReferenceType: TypeName{name=Foo, package=com.palantir.test}",
+			}},
+		},
+		{
+			output: "Error: Unable to access jarfile conjure.jar\n",
+		},
+	} {
+		cliPath := filepath.Join(dir, fmt.Sprintf("conjure-%d", i))
+		outputPath := filepath.Join(dir, fmt.Sprintf("output-%d", i))
+		require.NoError(t, ioutil.WriteFile(outputPath, []byte(tc.output), 0644))
+		require.NoError(t, ioutil.WriteFile(cliPath, []byte(`#!/bin/sh
+if [ "$1" = "--version" ]; then
+  echo "conjure 4.40.0"
+  exit 0
+fi
+cat "`+outputPath+`" >&2
+exit 1
+`), 0755))
+
+		_, err := conjureircli.InputPathToIRWithParams(inPath, conjureircli.CLIPathParam(cliPath))
+		require.Error(t, err, "Case %d", i)
+		var compileErr *conjureircli.CompileError
+		require.True(t, errors.As(err, &compileErr), "Case %d", i)
+		assert.Equal(t, inPath, compileErr.InputPath, "Case %d", i)
+		assert.Equal(t, tc.output, compileErr.Output, "Case %d", i)
+		assert.Equal(t, tc.wantDiagnostics, compileErr.Diagnostics, "Case %d", i)
+		if tc.wantErr != "" {
+			assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+		}
+		if len(tc.wantDiagnostics) == 0 {
+			assert.Contains(t, err.Error(), "Output:\n"+tc.output, "Case %d", i)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureircli
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mholt/archiver"
+	"github.com/pkg/errors"
+)
+
+const (
+	// checksumsAssetName is the name of the asset that records the SHA-256 checksums of the files in the bundled CLI
+	// archive. It is written by the generator in conjureircli/generator when the archive is bundled, and every line is
+	// of the form "<hex digest>  <path>", where the path is relative to the directory into which the archive is
+	// unpacked.
+	checksumsAssetName = "conjure.tgz.sha256"
+
+	// verifiedMarkerName is the name of the file that is written to an unpacked CLI directory once all of its files
+	// have been verified against the recorded checksums. Directories without the marker were not unpacked completely.
+	verifiedMarkerName = ".conjureircli-verified"
+)
+
+// unpackCLI unpacks the provided archive, whose files are all in the directory with the provided name, into the
+// provided directory. Concurrent invocations in any process are serialized using a lock file in the unpack directory.
+// The archive is extracted into a temporary directory and its files are verified against the provided checksums before
+// the archive directory is moved into place with an atomic rename, so an archive directory that exists is always
+// complete. Does nothing if the archive directory has already been unpacked and verified.
+func unpackCLI(unpackDir, archiveDirName string, tgzBytes []byte, checksums map[string]string) (rErr error) {
+	archiveDir := filepath.Join(unpackDir, archiveDirName)
+	if isVerifiedDir(archiveDir) {
+		return nil
+	}
+	if err := os.MkdirAll(unpackDir, 0755); err != nil {
+		return errors.Wrapf(err, "failed to create directory %s", unpackDir)
+	}
+	unlock, err := LockFile(filepath.Join(unpackDir, "."+archiveDirName+".lock"))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// another process may have unpacked the archive while this one was waiting for the lock
+	if isVerifiedDir(archiveDir) {
+		return nil
+	}
+
+	tmpDir, err := ioutil.TempDir(unpackDir, "."+archiveDirName+"-")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temporary directory")
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); rErr == nil && err != nil {
+			rErr = errors.Wrapf(err, "failed to remove temporary directory")
+		}
+	}()
+	if err := archiver.TarGz.Read(bytes.NewReader(tgzBytes), tmpDir); err != nil {
+		return errors.Wrapf(err, "failed to unpack CLI archive")
+	}
+	if err := verifyChecksums(tmpDir, checksums); err != nil {
+		return errors.Wrapf(err, "failed to verify unpacked CLI archive")
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, archiveDirName, verifiedMarkerName), nil, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// remove any directory left behind by an unpacking that did not complete
+	if err := os.RemoveAll(archiveDir); err != nil {
+		return errors.Wrapf(err, "failed to remove incomplete CLI directory %s", archiveDir)
+	}
+	if err := os.Rename(filepath.Join(tmpDir, archiveDirName), archiveDir); err != nil {
+		return errors.Wrapf(err, "failed to move unpacked CLI into place")
+	}
+	return nil
+}
+
+func isVerifiedDir(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, verifiedMarkerName))
+	return err == nil
+}
+
+// bundledChecksums returns the checksums of the files in the provided bundled CLI archive. The checksums are read from
+// the checksums asset returned by the provided asset function. Bundled data generated before the generator wrote the
+// checksums asset does not contain it: in that case, the checksums are computed from the archive itself, which still
+// verifies that the unpacked directory is complete.
+func bundledChecksums(tgzBytes []byte, asset func(name string) ([]byte, error)) (map[string]string, error) {
+	checksumsBytes, err := asset(checksumsAssetName)
+	if err != nil {
+		checksums, err := archiveChecksums(tgzBytes)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to compute checksums of bundled CLI archive")
+		}
+		return checksums, nil
+	}
+	checksums, err := parseChecksums(checksumsBytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse checksums of bundled CLI")
+	}
+	return checksums, nil
+}
+
+// archiveChecksums returns a map from path to hex-encoded SHA-256 digest for the regular files in the provided tgz
+// archive. The paths match the ones written by the generator in conjureircli/generator.
+func archiveChecksums(tgzBytes []byte) (map[string]string, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(tgzBytes))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	checksums := make(map[string]string)
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		h := sha256.New()
+		if _, err := io.Copy(h, tarReader); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		checksums[path.Clean(header.Name)] = fmt.Sprintf("%x", h.Sum(nil))
+	}
+	return checksums, nil
+}
+
+// parseChecksums parses the content of the checksums asset into a map from path to hex-encoded SHA-256 digest.
+func parseChecksums(in []byte) (map[string]string, error) {
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(in))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "  ", 2)
+		if len(parts) != 2 || len(parts[0]) != sha256.Size*2 {
+			return nil, errors.Errorf("invalid checksum on line %d: %q", lineNum, line)
+		}
+		checksums[parts[1]] = parts[0]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return checksums, nil
+}
+
+// verifyChecksums verifies that the regular files in the provided directory are exactly the files in the provided
+// checksums and that the content of every file matches its checksum.
+func verifyChecksums(dir string, checksums map[string]string) error {
+	if len(checksums) == 0 {
+		return errors.Errorf("no checksums were recorded for the CLI archive")
+	}
+	seen := make(map[string]struct{})
+	if err := filepath.Walk(dir, func(currPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, currPath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		want, ok := checksums[relPath]
+		if !ok {
+			return errors.Errorf("file %s does not have a recorded checksum", relPath)
+		}
+		got, err := fileSHA256(currPath)
+		if err != nil {
+			return err
+		}
+		if got != want {
+			return errors.Errorf("checksum of file %s does not match\nExpected: %s\nActual:   %s", relPath, want, got)
+		}
+		seen[relPath] = struct{}{}
+		return nil
+	}); err != nil {
+		return err
+	}
+	var missing []string
+	for relPath := range checksums {
+		if _, ok := seen[relPath]; !ok {
+			missing = append(missing, relPath)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return errors.Errorf("files with recorded checksums are missing: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func fileSHA256(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2026 Palantir Technologies. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conjureircli
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnpackCLI(t *testing.T) {
+	files := map[string]string{
+		"conjure-1.0.0/bin/conjure":     "#!/bin/sh\n",
+		"conjure-1.0.0/lib/conjure.jar": "jar content",
+	}
+	tgzBytes := testTGZ(t, files)
+	checksumsContent := ""
+	for _, name := range []string{"conjure-1.0.0/bin/conjure", "conjure-1.0.0/lib/conjure.jar"} {
+		checksumsContent += fmt.Sprintf("%x  %s\n", sha256.Sum256([]byte(files[name])), name)
+	}
+	checksums, err := parseChecksums([]byte(checksumsContent))
+	require.NoError(t, err)
+
+	unpackDir := t.TempDir()
+	archiveDir := filepath.Join(unpackDir, "conjure-1.0.0")
+
+	// a directory left behind by an incomplete unpacking is replaced
+	require.NoError(t, os.MkdirAll(filepath.Join(archiveDir, "bin"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(archiveDir, "bin", "conjure"), nil, 0755))
+
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = unpackCLI(unpackDir, "conjure-1.0.0", tgzBytes, checksums)
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		require.NoError(t, err, "Case %d", i)
+	}
+	for name, content := range files {
+		got, err := ioutil.ReadFile(filepath.Join(unpackDir, name))
+		require.NoError(t, err)
+		assert.Equal(t, content, string(got))
+	}
+	assert.True(t, isVerifiedDir(archiveDir))
+
+	// only the archive directory and the lock file remain in the unpack directory
+	entries, err := ioutil.ReadDir(unpackDir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{".conjure-1.0.0.lock", "conjure-1.0.0"}, names)
+}
+
+func TestUnpackCLIChecksumMismatch(t *testing.T) {
+	tgzBytes := testTGZ(t, map[string]string{
+		"conjure-1.0.0/bin/conjure": "#!/bin/sh\n",
+		"conjure-1.0.0/extra.txt":   "extra",
+	})
+	digest := fmt.Sprintf("%x", sha256.Sum256([]byte("#!/bin/sh\n")))
+	for i, tc := range []struct {
+		checksums map[string]string
+		wantErr   string
+	}{
+		{
+			checksums: map[string]string{
+				"conjure-1.0.0/bin/conjure": digest,
+			},
+			wantErr: "failed to verify unpacked CLI archive: file conjure-1.0.0/extra.txt does not have a recorded checksum",
+		},
+		{
+			checksums: map[string]string{
+				"conjure-1.0.0/bin/conjure": digest,
+				"conjure-1.0.0/extra.txt":   digest,
+			},
+			wantErr: fmt.Sprintf("failed to verify unpacked CLI archive: checksum of file conjure-1.0.0/extra.txt does not match\nExpected: %s\nActual:   %x", digest, sha256.Sum256([]byte("extra"))),
+		},
+		{
+			checksums: map[string]string{
+				"conjure-1.0.0/bin/conjure": digest,
+				"conjure-1.0.0/extra.txt":   fmt.Sprintf("%x", sha256.Sum256([]byte("extra"))),
+				"conjure-1.0.0/lib/a.jar":   digest,
+			},
+			wantErr: "failed to verify unpacked CLI archive: files with recorded checksums are missing: conjure-1.0.0/lib/a.jar",
+		},
+	} {
+		unpackDir := t.TempDir()
+		err := unpackCLI(unpackDir, "conjure-1.0.0", tgzBytes, tc.checksums)
+		assert.EqualError(t, err, tc.wantErr, "Case %d", i)
+		_, err = os.Stat(filepath.Join(unpackDir, "conjure-1.0.0"))
+		assert.True(t, os.IsNotExist(err), "Case %d", i)
+	}
+}
+
+func TestBundledChecksums(t *testing.T) {
+	files := map[string]string{
+		"conjure-1.0.0/bin/conjure":     "#!/bin/sh\n",
+		"conjure-1.0.0/lib/conjure.jar": "jar content",
+	}
+	tgzBytes := testTGZ(t, files)
+	wantChecksums := map[string]string{
+		"conjure-1.0.0/bin/conjure":     fmt.Sprintf("%x", sha256.Sum256([]byte(files["conjure-1.0.0/bin/conjure"]))),
+		"conjure-1.0.0/lib/conjure.jar": fmt.Sprintf("%x", sha256.Sum256([]byte(files["conjure-1.0.0/lib/conjure.jar"]))),
+	}
+
+	// the checksums asset is used when it is bundled
+	checksums, err := bundledChecksums(tgzBytes, func(name string) ([]byte, error) {
+		require.Equal(t, checksumsAssetName, name)
+		return []byte(wantChecksums["conjure-1.0.0/bin/conjure"] + "  conjure-1.0.0/bin/conjure\n"), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"conjure-1.0.0/bin/conjure": wantChecksums["conjure-1.0.0/bin/conjure"]}, checksums)
+
+	_, err = bundledChecksums(tgzBytes, func(name string) ([]byte, error) {
+		return []byte("invalid\n"), nil
+	})
+	assert.EqualError(t, err, `failed to parse checksums of bundled CLI: invalid checksum on line 1: "invalid"`)
+
+	// the checksums are computed from the archive when the checksums asset is missing
+	checksums, err = bundledChecksums(tgzBytes, func(name string) ([]byte, error) {
+		return nil, fmt.Errorf("Asset %s not found", name)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, wantChecksums, checksums)
+
+	unpackDir := t.TempDir()
+	require.NoError(t, unpackCLI(unpackDir, "conjure-1.0.0", tgzBytes, checksums))
+	assert.True(t, isVerifiedDir(filepath.Join(unpackDir, "conjure-1.0.0")))
+
+	_, err = bundledChecksums([]byte("not a tgz archive"), func(name string) ([]byte, error) {
+		return nil, fmt.Errorf("Asset %s not found", name)
+	})
+	assert.EqualError(t, err, "failed to compute checksums of bundled CLI archive: gzip: invalid header")
+}
+
+func testTGZ(t *testing.T, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, content := range files {
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0755,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tarWriter.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+	require.NoError(t, gzipWriter.Close())
+	return buf.Bytes()
+}